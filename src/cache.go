@@ -0,0 +1,34 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scrapecache"
+)
+
+// runCacheCommand handles "cache <subcommand>"
+func runCacheCommand(cfg *Config, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: cache prune [--all]")
+	}
+
+	switch args[0] {
+	case "prune":
+		flags := flag.NewFlagSet("cache prune", flag.ContinueOnError)
+		all := flags.Bool("all", false, "remove every cached scrape, not only expired ones")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		cache := scrapecache.New(cfg.ScrapeCacheDir, cfg.ScrapeCacheTTL)
+		removed, kept, err := cache.Prune(*all)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ Pruned scrape cache %s: %d removed, %d kept\n", cache.Dir(), removed, kept)
+		return nil
+	default:
+		return fmt.Errorf("unknown cache command %q (expected prune)", args[0])
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/cassette"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/httpfetch"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/images"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/notion"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraperules"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/tables/bookmarks"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/uploadcache"
+)
+
+// clients holds the Notion, scraper and upload clients of a run, all sending their
+// requests through the configured cassette mode
+type clients struct {
+	notion          *notion.Client
+	bookmarkService *bookmarks.Service
+	scraper         scraper.Scraper
+	imageUploader   *notion.ImageUploader // nil when image upload is disabled
+	scrapeRules     *scraperules.Rules    // nil without a rules file
+	fetch           httpfetch.Options
+	recorder        *cassette.Recorder // set in record mode
+	replayer        *cassette.Replayer // set in replay mode
+	newNative       func() scraper.Scraper
+}
+
+// newClients creates the clients for a configuration
+func newClients(cfg *Config) (*clients, error) {
+	c := &clients{}
+
+	// Every URL taken from scraped pages goes through the same policy
+	urlPolicy := cfg.URLPolicy()
+
+	// Record or replay all HTTP traffic when a cassette mode is set
+	// transport carries the scraper and Notion requests; fetchTransport the pages, images
+	// and APIs fetched from scraped URLs, which keep the policy's address checks when recording
+	var transport, fetchTransport http.RoundTripper
+	switch cfg.CassetteMode {
+	case cassette.ModeRecord:
+		recorder, err := cassette.NewRecorder(cfg.CassettePath, cassette.RecorderOptions{
+			Secrets: []string{cfg.NotionAPIKey, cfg.GitHubToken},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to start recording: %w", err)
+		}
+		c.recorder = recorder
+		transport = recorder
+		fetchTransport = recorder.WithTransport(urlPolicy.Transport())
+		fmt.Printf("✓ Recording HTTP traffic to %s\n", cfg.CassettePath)
+	case cassette.ModeReplay:
+		replayer, err := cassette.LoadReplayer(cfg.CassettePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load cassette: %w", err)
+		}
+		// Requests missing from the cassette fail instead of reaching the network
+		c.replayer = replayer
+		transport = replayer
+		fetchTransport = replayer
+		fmt.Printf("✓ Replaying HTTP traffic from %s (%d interactions)\n", cfg.CassettePath, replayer.Remaining())
+	}
+	c.fetch = httpfetch.Options{Policy: urlPolicy, Transport: fetchTransport}
+
+	c.notion = notion.NewClientWithOptions(cfg.NotionAPIKey, cfg.BookmarksDBID, cfg.TagsDBID, cfg.ManualListDBID, cfg.SmartListDBID,
+		notion.ClientOptions{Transport: transport})
+	c.bookmarkService = bookmarks.NewService(c.notion)
+	c.bookmarkService.SetMediaProperty(cfg.MediaProperty)
+
+	// Transient scrape failures (timeouts, 429 and 5xx responses) are retried with backoff
+	retry := scraper.RetryPolicy{
+		Retries:       cfg.ScraperRetries,
+		Backoff:       cfg.ScraperRetryBackoff,
+		MaxBackoff:    cfg.ScraperRetryMaxBackoff,
+		RetryTimeouts: cfg.ScraperRetryTimeouts,
+		OnRetry: func(attempt int, err error, wait time.Duration) {
+			fmt.Printf("  ⏳ Scrape attempt %d failed (%v), retrying in %s\n", attempt, err, wait.Round(100*time.Millisecond))
+		},
+	}
+
+	// Scrape rules change how matching sites are scraped, whichever scraper is used
+	if cfg.ScrapeRulesFile != "" {
+		scrapeRules, err := scraperules.Load(cfg.ScrapeRulesFile)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to load scrape rules: %w", err)
+		}
+		// webmeatscraper's /scrape takes only a URL, so it can't send the extra headers
+		if names := scrapeRules.HeaderRules(); len(names) > 0 && cfg.ScraperBackend != scraper.BackendNative {
+			c.Close()
+			return nil, fmt.Errorf("failed to load scrape rules: headers need SCRAPER_BACKEND=native (rules: %s)", strings.Join(names, ", "))
+		}
+		c.scrapeRules = scrapeRules
+		fmt.Printf("✓ Scrape rules: %d rule(s) from %s\n", scrapeRules.Len(), cfg.ScrapeRulesFile)
+	}
+
+	// The built-in scraper fetches bookmarked pages itself, so the URL policy applies to them too
+	c.newNative = func() scraper.Scraper {
+		return scraper.NewNative(scraper.NativeOptions{
+			Policy:    urlPolicy,
+			Retry:     retry,
+			Rules:     c.scrapeRules,
+			Transport: fetchTransport,
+		})
+	}
+
+	if cfg.ScraperBackend == scraper.BackendNative {
+		c.scraper = c.newNative()
+	} else {
+		// Default to localhost if not set in config
+		scraperURLs := cfg.WebmeatscraperURLs
+		if len(scraperURLs) == 0 {
+			scraperURLs = []string{"http://localhost:7878"}
+		}
+		c.scraper = scraper.NewClientWithOptions(scraperURLs, scraper.ClientOptions{
+			Retry:     retry,
+			Cooldown:  cfg.ScraperCooldown,
+			Transport: transport,
+			Rules:     c.scrapeRules,
+		})
+	}
+
+	// Initialize image uploader if enabled
+	if cfg.UploadImagesToNotion {
+		options := notion.ImageUploaderOptions{
+			Mode:             cfg.ImageUploadMode,
+			MaxDownloadBytes: cfg.ImageDownloadMaxBytes,
+			Policy:           urlPolicy,
+			Transport:        fetchTransport,
+		}
+		if cfg.ImageTranscodeEnabled {
+			transcoder := images.NewTranscoder(images.TranscodeOptions{
+				Format:       cfg.ImageTranscodeFormat,
+				MaxDimension: cfg.ImageMaxDimension,
+				MaxBytes:     cfg.ImageMaxUploadBytes,
+				IconSize:     cfg.FaviconSize,
+			})
+			options.Transformer = transcoder.Transform
+		}
+		// A replayed run must make the recorded requests, so local caches are left out
+		if cfg.UploadCacheEnabled && cfg.CassetteMode != cassette.ModeReplay {
+			cache, err := uploadcache.Open(cfg.UploadCachePath)
+			if err != nil {
+				log.Printf("Upload cache disabled: %v", err)
+			} else {
+				options.Cache = cache
+			}
+		}
+		c.imageUploader = notion.NewImageUploader(
+			c.notion,
+			cfg.ImageUploadTimeout,
+			cfg.ImageUploadPollInterval,
+			options,
+		)
+		fmt.Printf("✓ Image upload to Notion: ENABLED (mode: %s)\n", cfg.ImageUploadMode)
+		if cfg.ImageTranscodeEnabled && cfg.ImageUploadMode != notion.UploadModeExternal {
+			fmt.Printf("✓ Image transcoding: ENABLED (format: %s, max %dpx)\n", cfg.ImageTranscodeFormat, cfg.ImageMaxDimension)
+		}
+		if options.Cache != nil {
+			fmt.Printf("✓ Upload cache: ENABLED (%d entries in %s)\n", options.Cache.Len(), cfg.UploadCachePath)
+		}
+	} else {
+		fmt.Println("  Image upload to Notion: disabled")
+	}
+
+	return c, nil
+}
+
+// newProcessor creates a processor using these clients
+func (c *clients) newProcessor(cfg *Config) *Processor {
+	processor := NewProcessor(cfg, c.notion, c.bookmarkService, c.scraper, c.imageUploader, c.fetch)
+	if c.scrapeRules != nil {
+		processor.SetScrapeRules(c.scrapeRules)
+	}
+	return processor
+}
+
+// Close closes the cassette being recorded, if any
+func (c *clients) Close() error {
+	if c.recorder != nil {
+		return c.recorder.Close()
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Command fake-scraper serves canned scrape results with the webmeatscraper API,
+// for local development without the real scraper image
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/fakescraper"
+)
+
+func main() {
+	addr := flag.String("addr", ":7878", "address to listen on")
+	fixturesDir := flag.String("fixtures", "fixtures", "directory of *.json fixture files")
+	latency := flag.Duration("latency", 0, "delay added before every scrape answer")
+	jitter := flag.Duration("jitter", 0, "random extra delay of up to this much")
+	errorRate := flag.Float64("error-rate", 0, "fraction of scrapes (0 to 1) that fail")
+	errorStatus := flag.Int("error-status", http.StatusServiceUnavailable, "status of injected failures")
+	strict := flag.Bool("strict", false, "answer 404 for URLs no fixture matches instead of generating a page")
+	flag.Parse()
+
+	if *errorRate < 0 || *errorRate > 1 {
+		log.Fatalf("-error-rate must be between 0 and 1")
+	}
+
+	fixtures, err := fakescraper.LoadFixtures(*fixturesDir)
+	if err != nil {
+		log.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	server := fakescraper.New(fixtures, fakescraper.Options{
+		Latency:     *latency,
+		Jitter:      *jitter,
+		ErrorRate:   *errorRate,
+		ErrorStatus: *errorStatus,
+		Strict:      *strict,
+		Logf:        log.Printf,
+	})
+	httpServer := &http.Server{Addr: *addr, Handler: server}
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		select {
+		case <-server.Exited():
+		case <-signals:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpServer.Shutdown(ctx)
+	}()
+
+	log.Printf("Fake scraper listening on %s with %d fixture(s) from %s", *addr, len(fixtures), *fixturesDir)
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Server failed: %v", err)
+	}
+}
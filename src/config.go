@@ -4,25 +4,118 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/cassette"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/fieldmap"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/images"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/notion"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/overwrite"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/platforms"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/urlpolicy"
 )
 
 // Config holds the application configuration
 type Config struct {
-	NotionAPIKey      string
-	BookmarksDBID     string
-	TagsDBID          string
-	ManualListDBID    string
-	SmartListDBID     string
-	WebmeatscraperURL string
+	NotionAPIKey       string
+	BookmarksDBID      string
+	TagsDBID           string
+	ManualListDBID     string
+	SmartListDBID      string
+	WebmeatscraperURLs []string
+
+	// Scraper configuration
+	ScraperBackend          scraper.Backend
+	ScraperRetries          int
+	ScraperRetryBackoff     time.Duration
+	ScraperRetryMaxBackoff  time.Duration
+	ScraperRetryTimeouts    bool
+	ScraperCooldown         time.Duration
+	ScrapeDomainConcurrency int
+	ScrapeDomainDelay       time.Duration
+	ScraperReadyTimeout     time.Duration
+	ScraperReadyBackoff     time.Duration
+	ScraperReadyMaxBackoff  time.Duration
+	ScraperDegradedMode     bool
+	ScraperLifecycle        scraper.Lifecycle
+	ScraperCommand          string
+	ScraperMaxRestarts      int
+	ScraperStopTimeout      time.Duration
 
 	// Image upload configuration
 	UploadImagesToNotion    bool
 	ImageUploadTimeout      time.Duration
 	ImageUploadPollInterval time.Duration
 	FallbackToExternalURL   bool
+	ImageUploadMode         notion.UploadMode
+	ImageDownloadMaxBytes   int64
+
+	// Image transcoding configuration (direct uploads only)
+	ImageTranscodeEnabled bool
+	ImageTranscodeFormat  images.Format
+	ImageMaxDimension     int
+	ImageMaxUploadBytes   int
+	FaviconSize           int
+
+	// Media property configuration
+	MediaProperty       string
+	MediaIncludeFavicon bool
+
+	// Upload cache configuration
+	UploadCacheEnabled bool
+	UploadCachePath    string
+
+	// Scrape cache configuration
+	ScrapeCacheEnabled bool
+	ScrapeCacheDir     string
+	ScrapeCacheTTL     time.Duration
+
+	// URL policy configuration (applies to every fetched or imported URL)
+	URLAllowHosts           []string
+	URLDenyHosts            []string
+	URLAllowPrivateNetworks bool
+	URLMaxRedirects         int
+
+	// Image selection configuration
+	ImageSelectionEnabled bool
+	ImageMinWidth         int
+	ImageMinHeight        int
+	ImageMaxCandidates    int
+	ImageProbeTimeout     time.Duration
+
+	// Placeholder cover configuration
+	PlaceholderCoversEnabled bool
+
+	// Favicon configuration
+	FaviconDiscoveryEnabled bool
+
+	// Page content configuration
+	RichEmbedsEnabled bool
+
+	// Snapshot configuration
+	SnapshotEnabled  bool
+	SnapshotMaxBytes int
+	SnapshotProperty string
+
+	// Platform metadata configuration
+	PlatformProperties []platforms.Mapping
+	GitHubToken        string
+
+	// Scrape rules configuration (per-site skip, rewrite, timeout, headers and tags)
+	ScrapeRulesFile string
+
+	// Scraped field to bookmark property mappings (defaults filled in for unmapped properties)
+	FieldMappings []fieldmap.Mapping
+
+	// Overwrite policies for fields that may already hold a value
+	Overwrite overwrite.Fields
+
+	// HTTP cassette configuration (record or replay scraper and Notion traffic)
+	CassetteMode cassette.Mode
+	CassettePath string
 
 	// Debug configuration
 	Debug bool
@@ -35,23 +128,162 @@ func Load() (*Config, error) {
 	_ = godotenv.Load()
 
 	cfg := &Config{
-		NotionAPIKey:      os.Getenv("NOTION_API_KEY"),
-		BookmarksDBID:     os.Getenv("NOTION_BOOKMARKS_DB_ID"),
-		TagsDBID:          os.Getenv("NOTION_TAGS_DB_ID"),
-		ManualListDBID:    os.Getenv("NOTION_MANUALLIST_DB_ID"),
-		SmartListDBID:     os.Getenv("NOTION_SMARTLIST_DB_ID"),
-		WebmeatscraperURL: os.Getenv("WEBMEATSCRAPER_URL"),
+		NotionAPIKey:       os.Getenv("NOTION_API_KEY"),
+		BookmarksDBID:      os.Getenv("NOTION_BOOKMARKS_DB_ID"),
+		TagsDBID:           os.Getenv("NOTION_TAGS_DB_ID"),
+		ManualListDBID:     os.Getenv("NOTION_MANUALLIST_DB_ID"),
+		SmartListDBID:      os.Getenv("NOTION_SMARTLIST_DB_ID"),
+		WebmeatscraperURLs: scraper.ParseBaseURLs(os.Getenv("WEBMEATSCRAPER_URL")),
+
+		// Parse scraper retry and politeness settings with defaults
+		ScraperRetries:          parseIntWithDefault(os.Getenv("SCRAPER_RETRIES"), 2),
+		ScraperRetryBackoff:     parseDurationWithDefault(os.Getenv("SCRAPER_RETRY_BACKOFF"), 2*time.Second),
+		ScraperRetryMaxBackoff:  parseDurationWithDefault(os.Getenv("SCRAPER_RETRY_MAX_BACKOFF"), 30*time.Second),
+		ScraperRetryTimeouts:    parseBoolWithDefault(os.Getenv("SCRAPER_RETRY_TIMEOUTS"), false),
+		ScraperCooldown:         parseDurationWithDefault(os.Getenv("SCRAPER_UNHEALTHY_COOLDOWN"), 30*time.Second),
+		ScrapeDomainConcurrency: parseIntWithDefault(os.Getenv("SCRAPE_DOMAIN_CONCURRENCY"), 1),
+		ScrapeDomainDelay:       parseDurationWithDefault(os.Getenv("SCRAPE_DOMAIN_DELAY"), time.Second),
+
+		// Parse scraper readiness settings with defaults
+		ScraperReadyTimeout:    parseDurationWithDefault(os.Getenv("SCRAPER_READY_TIMEOUT"), 60*time.Second),
+		ScraperReadyBackoff:    parseDurationWithDefault(os.Getenv("SCRAPER_READY_BACKOFF"), time.Second),
+		ScraperReadyMaxBackoff: parseDurationWithDefault(os.Getenv("SCRAPER_READY_MAX_BACKOFF"), 10*time.Second),
+		ScraperDegradedMode:    parseBoolWithDefault(os.Getenv("SCRAPER_DEGRADED_MODE"), false),
+
+		// Parse scraper lifecycle settings with defaults
+		ScraperCommand:     os.Getenv("SCRAPER_COMMAND"),
+		ScraperMaxRestarts: parseIntWithDefault(os.Getenv("SCRAPER_MAX_RESTARTS"), 5),
+		ScraperStopTimeout: parseDurationWithDefault(os.Getenv("SCRAPER_STOP_TIMEOUT"), 10*time.Second),
 
 		// Parse image upload settings with defaults
 		UploadImagesToNotion:    parseBoolWithDefault(os.Getenv("UPLOAD_IMAGES_TO_NOTION"), true),
 		ImageUploadTimeout:      parseDurationWithDefault(os.Getenv("IMAGE_UPLOAD_TIMEOUT"), 30*time.Second),
 		ImageUploadPollInterval: parseDurationWithDefault(os.Getenv("IMAGE_UPLOAD_POLL_INTERVAL"), 3*time.Second),
 		FallbackToExternalURL:   parseBoolWithDefault(os.Getenv("FALLBACK_TO_EXTERNAL_URL"), true),
+		ImageDownloadMaxBytes:   int64(parseIntWithDefault(os.Getenv("IMAGE_DOWNLOAD_MAX_BYTES"), 50*1024*1024)),
+
+		// Parse image transcoding settings with defaults
+		ImageTranscodeEnabled: parseBoolWithDefault(os.Getenv("IMAGE_TRANSCODE_ENABLED"), true),
+		ImageMaxDimension:     parseIntWithDefault(os.Getenv("IMAGE_MAX_DIMENSION"), 2000),
+		ImageMaxUploadBytes:   parseIntWithDefault(os.Getenv("IMAGE_MAX_UPLOAD_BYTES"), 5*1024*1024),
+		FaviconSize:           parseIntWithDefault(os.Getenv("FAVICON_SIZE"), 256),
+
+		// Parse media property settings with defaults
+		MediaProperty:       os.Getenv("MEDIA_PROPERTY"),
+		MediaIncludeFavicon: parseBoolWithDefault(os.Getenv("MEDIA_INCLUDE_FAVICON"), false),
+
+		// Parse upload cache settings with defaults
+		UploadCacheEnabled: parseBoolWithDefault(os.Getenv("UPLOAD_CACHE_ENABLED"), true),
+		UploadCachePath:    getEnvWithDefault("UPLOAD_CACHE_PATH", ".cache/uploads.json"),
+
+		// Parse URL policy settings with defaults
+		URLAllowHosts:           urlpolicy.ParseHostList(os.Getenv("URL_ALLOW_HOSTS")),
+		URLDenyHosts:            urlpolicy.ParseHostList(os.Getenv("URL_DENY_HOSTS")),
+		URLAllowPrivateNetworks: parseBoolWithDefault(os.Getenv("URL_ALLOW_PRIVATE_NETWORKS"), false),
+		URLMaxRedirects:         parseIntWithDefault(os.Getenv("URL_MAX_REDIRECTS"), urlpolicy.DefaultMaxRedirects),
+
+		// Parse image selection settings with defaults
+		ImageSelectionEnabled: parseBoolWithDefault(os.Getenv("IMAGE_SELECTION_ENABLED"), true),
+		ImageMinWidth:         parseIntWithDefault(os.Getenv("IMAGE_MIN_WIDTH"), 200),
+		ImageMinHeight:        parseIntWithDefault(os.Getenv("IMAGE_MIN_HEIGHT"), 100),
+		ImageMaxCandidates:    parseIntWithDefault(os.Getenv("IMAGE_MAX_CANDIDATES"), 8),
+		ImageProbeTimeout:     parseDurationWithDefault(os.Getenv("IMAGE_PROBE_TIMEOUT"), 10*time.Second),
+
+		// Parse placeholder cover settings with defaults
+		PlaceholderCoversEnabled: parseBoolWithDefault(os.Getenv("PLACEHOLDER_COVERS_ENABLED"), false),
+
+		// Parse favicon settings with defaults
+		FaviconDiscoveryEnabled: parseBoolWithDefault(os.Getenv("FAVICON_DISCOVERY_ENABLED"), true),
+
+		// Parse page content settings with defaults
+		RichEmbedsEnabled: parseBoolWithDefault(os.Getenv("RICH_EMBEDS_ENABLED"), true),
+
+		// Parse snapshot settings with defaults
+		SnapshotEnabled:  parseBoolWithDefault(os.Getenv("SNAPSHOT_ENABLED"), false),
+		SnapshotMaxBytes: parseIntWithDefault(os.Getenv("SNAPSHOT_MAX_BYTES"), 5*1024*1024),
+		SnapshotProperty: os.Getenv("SNAPSHOT_PROPERTY"),
+
+		// Parse platform metadata settings
+		GitHubToken: os.Getenv("GITHUB_TOKEN"),
+
+		// Parse scrape rules settings
+		ScrapeRulesFile: os.Getenv("SCRAPE_RULES_FILE"),
+
+		// Parse HTTP cassette settings with defaults
+		CassettePath: getEnvWithDefault("HTTP_CASSETTE_PATH", ".cache/cassettes/run.jsonl"),
 
 		// Parse debug settings with defaults
 		Debug: parseBoolWithDefault(os.Getenv("DEBUG"), false),
 	}
 
+	loadScrapeCacheSettings(cfg)
+
+	// Parse upload mode (validated here since unknown values are an error, not a default)
+	// Downloading images locally is opt-in; by default Notion fetches them itself
+	uploadMode, err := notion.ParseUploadMode(getEnvWithDefault("IMAGE_UPLOAD_MODE", string(notion.UploadModeExternal)))
+	if err != nil {
+		return nil, fmt.Errorf("IMAGE_UPLOAD_MODE: %w", err)
+	}
+	cfg.ImageUploadMode = uploadMode
+
+	scraperBackend, err := scraper.ParseBackend(getEnvWithDefault("SCRAPER_BACKEND", string(scraper.BackendWebmeatscraper)))
+	if err != nil {
+		return nil, fmt.Errorf("SCRAPER_BACKEND: %w", err)
+	}
+	cfg.ScraperBackend = scraperBackend
+
+	scraperLifecycle, err := scraper.ParseLifecycle(getEnvWithDefault("SCRAPER_LIFECYCLE", string(scraper.LifecycleShared)))
+	if err != nil {
+		return nil, fmt.Errorf("SCRAPER_LIFECYCLE: %w", err)
+	}
+	cfg.ScraperLifecycle = scraperLifecycle
+
+	cassetteMode, err := cassette.ParseMode(getEnvWithDefault("HTTP_CASSETTE_MODE", string(cassette.ModeOff)))
+	if err != nil {
+		return nil, fmt.Errorf("HTTP_CASSETTE_MODE: %w", err)
+	}
+	cfg.CassetteMode = cassetteMode
+
+	// Parse overwrite policies (defaults keep the previous behaviour)
+	overwritePolicies := []struct {
+		env      string
+		fallback overwrite.Policy
+		target   *overwrite.Policy
+	}{
+		{"OVERWRITE_COVER", overwrite.Always, &cfg.Overwrite.Cover},
+		{"OVERWRITE_ICON", overwrite.IfEmpty, &cfg.Overwrite.Icon},
+		{"OVERWRITE_AUTHOR", overwrite.IfEmpty, &cfg.Overwrite.Author},
+		{"OVERWRITE_IMAGE", overwrite.IfEmpty, &cfg.Overwrite.Image},
+		{"OVERWRITE_TITLE", overwrite.Never, &cfg.Overwrite.Title},
+		{"OVERWRITE_SUMMARY", overwrite.Never, &cfg.Overwrite.Summary},
+		{"OVERWRITE_DATE_PUBLISHED", overwrite.IfEmpty, &cfg.Overwrite.DatePublished},
+	}
+	for _, field := range overwritePolicies {
+		policy, err := overwrite.Parse(getEnvWithDefault(field.env, string(field.fallback)))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.env, err)
+		}
+		*field.target = policy
+	}
+
+	fieldMappings, err := fieldmap.Parse(os.Getenv("FIELD_MAPPINGS"))
+	if err != nil {
+		return nil, fmt.Errorf("FIELD_MAPPINGS: %w", err)
+	}
+	cfg.FieldMappings = fieldmap.Merge(fieldmap.Defaults(), fieldMappings)
+
+	platformProperties, err := platforms.ParseMappings(os.Getenv("PLATFORM_PROPERTIES"))
+	if err != nil {
+		return nil, fmt.Errorf("PLATFORM_PROPERTIES: %w", err)
+	}
+	cfg.PlatformProperties = platformProperties
+
+	transcodeFormat, err := images.ParseFormat(getEnvWithDefault("IMAGE_TRANSCODE_FORMAT", string(images.FormatAuto)))
+	if err != nil {
+		return nil, fmt.Errorf("IMAGE_TRANSCODE_FORMAT: %w", err)
+	}
+	cfg.ImageTranscodeFormat = transcodeFormat
+
 	if err := cfg.Validate(); err != nil {
 		return nil, err
 	}
@@ -59,6 +291,26 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// LoadCacheConfig reads only the scrape cache settings, so cache commands work
+// without Notion credentials
+func LoadCacheConfig() (*Config, error) {
+	_ = godotenv.Load()
+
+	cfg := &Config{}
+	loadScrapeCacheSettings(cfg)
+	if cfg.ScrapeCacheTTL < 0 {
+		return nil, fmt.Errorf("SCRAPE_CACHE_TTL must not be negative")
+	}
+	return cfg, nil
+}
+
+// loadScrapeCacheSettings parses the scrape cache settings with defaults
+func loadScrapeCacheSettings(cfg *Config) {
+	cfg.ScrapeCacheEnabled = parseBoolWithDefault(os.Getenv("SCRAPE_CACHE_ENABLED"), true)
+	cfg.ScrapeCacheDir = getEnvWithDefault("SCRAPE_CACHE_DIR", ".cache/scrapes")
+	cfg.ScrapeCacheTTL = parseDurationWithDefault(os.Getenv("SCRAPE_CACHE_TTL"), 24*time.Hour)
+}
+
 // Validate checks that all required configuration values are present
 func (c *Config) Validate() error {
 	if c.NotionAPIKey == "" {
@@ -76,10 +328,74 @@ func (c *Config) Validate() error {
 	if c.SmartListDBID == "" {
 		return fmt.Errorf("NOTION_SMARTLIST_DB_ID is required")
 	}
-	// WebmeatscraperURL is optional - will default to localhost:7878 if not set
+	if c.SnapshotMaxBytes <= 0 || c.SnapshotMaxBytes > 20*1024*1024 {
+		return fmt.Errorf("SNAPSHOT_MAX_BYTES must be between 1 and 20971520 (single-part upload limit)")
+	}
+	if c.MediaProperty != "" && c.MediaProperty == c.SnapshotProperty {
+		return fmt.Errorf("MEDIA_PROPERTY and SNAPSHOT_PROPERTY must be different properties")
+	}
+	if c.CassetteMode != cassette.ModeOff && c.CassettePath == "" {
+		return fmt.Errorf("HTTP_CASSETTE_PATH is required when HTTP_CASSETTE_MODE is %s", c.CassetteMode)
+	}
+	if c.ScraperRetries < 0 {
+		return fmt.Errorf("SCRAPER_RETRIES must not be negative")
+	}
+	if c.ScrapeDomainConcurrency < 1 {
+		return fmt.Errorf("SCRAPE_DOMAIN_CONCURRENCY must be at least 1")
+	}
+	if c.ScrapeDomainDelay < 0 {
+		return fmt.Errorf("SCRAPE_DOMAIN_DELAY must not be negative")
+	}
+	if c.ScraperReadyTimeout < 0 {
+		return fmt.Errorf("SCRAPER_READY_TIMEOUT must not be negative")
+	}
+	if c.ScraperLifecycle == scraper.LifecycleManaged && strings.TrimSpace(c.ScraperCommand) == "" {
+		return fmt.Errorf("SCRAPER_COMMAND is required when SCRAPER_LIFECYCLE is managed")
+	}
+	if c.ScraperMaxRestarts < 1 {
+		return fmt.Errorf("SCRAPER_MAX_RESTARTS must be at least 1")
+	}
+	if c.ScrapeCacheTTL < 0 {
+		return fmt.Errorf("SCRAPE_CACHE_TTL must not be negative")
+	}
+	if c.URLMaxRedirects < 0 {
+		return fmt.Errorf("URL_MAX_REDIRECTS must not be negative")
+	}
+	if c.ImageMaxDimension < 0 || c.ImageMaxUploadBytes < 0 {
+		return fmt.Errorf("IMAGE_MAX_DIMENSION and IMAGE_MAX_UPLOAD_BYTES must not be negative")
+	}
+	if c.FaviconSize <= 0 || c.FaviconSize > 1024 {
+		return fmt.Errorf("FAVICON_SIZE must be between 1 and 1024")
+	}
+	// WebmeatscraperURLs is optional - will default to localhost:7878 if not set,
+	// and is not used at all by the native scraper backend
 	return nil
 }
 
+// URLPolicy builds the URL policy shared by the uploader and local fetching
+func (c *Config) URLPolicy() *urlpolicy.Policy {
+	maxRedirects := c.URLMaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = -1 // 0 means "follow no redirects" in config
+	}
+	return urlpolicy.New(urlpolicy.Options{
+		AllowHosts:           c.URLAllowHosts,
+		DenyHosts:            c.URLDenyHosts,
+		AllowPrivateNetworks: c.URLAllowPrivateNetworks,
+		MaxRedirects:         maxRedirects,
+		// A replayed run only talks to the cassette, so hosts handed to Notion aren't resolved
+		SkipResolve: c.CassetteMode == cassette.ModeReplay,
+	})
+}
+
+// getEnvWithDefault returns an environment variable or a default value when unset
+func getEnvWithDefault(key, defaultVal string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultVal
+}
+
 // parseBoolWithDefault parses a boolean string with a default value
 func parseBoolWithDefault(value string, defaultVal bool) bool {
 	if value == "" {
@@ -92,6 +408,18 @@ func parseBoolWithDefault(value string, defaultVal bool) bool {
 	return parsed
 }
 
+// parseIntWithDefault parses an integer string with a default value
+func parseIntWithDefault(value string, defaultVal int) int {
+	if value == "" {
+		return defaultVal
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		return defaultVal
+	}
+	return parsed
+}
+
 // parseDurationWithDefault parses a duration string with a default value
 func parseDurationWithDefault(value string, defaultVal time.Duration) time.Duration {
 	if value == "" {
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/fieldmap"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/overwrite"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/tables/bookmarks"
+)
+
+// bookmarkField returns the bookmark field a mapped property is written to and its overwrite policy
+func bookmarkField(bookmark *bookmarks.Bookmark, policies overwrite.Fields, property string) (*string, overwrite.Policy) {
+	switch property {
+	case fieldmap.PropertyTitle:
+		return &bookmark.Title, policies.Title
+	case fieldmap.PropertySummary:
+		return &bookmark.Summary, policies.Summary
+	case fieldmap.PropertyAuthor:
+		return &bookmark.Author, policies.Author
+	case fieldmap.PropertyDatePublished:
+		return &bookmark.DatePublished, policies.DatePublished
+	}
+	return nil, overwrite.Never
+}
+
+// applyFieldMappings writes the mapped scraped fields into the bookmark as their overwrite policies allow
+// Returns true when any field changed
+func applyFieldMappings(bookmark *bookmarks.Bookmark, content *scraper.ScrapedContent, mappings []fieldmap.Mapping, policies overwrite.Fields) bool {
+	fields := fieldmap.Fields(content)
+	updated := false
+	for _, mapping := range mappings {
+		field, policy := bookmarkField(bookmark, policies, mapping.Property)
+		if field == nil {
+			continue
+		}
+		label := strings.ReplaceAll(mapping.Property, "_", " ")
+		updated = applyField(policy, field, mapping.Value(fields), label) || updated
+	}
+	return updated
+}
@@ -3,6 +3,13 @@ module github.com/pgodinho/hidrate-notion-bookmarks
 go 1.25.3
 
 require (
+	github.com/HugoSmits86/nativewebp v0.9.3
 	github.com/joho/godotenv v1.5.1
 	github.com/jomei/notionapi v1.13.3
+	github.com/srwiley/oksvg v0.0.0-20221011165216-be6e8873101c
+	github.com/srwiley/rasterx v0.0.0-20220730225603-2ab79fcdd4ef
+	golang.org/x/image v0.44.0
+	golang.org/x/net v0.52.0
 )
+
+require golang.org/x/text v0.40.0 // indirect
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/supervisor"
+)
+
+// scraperService starts, supervises and stops the scraper service as SCRAPER_LIFECYCLE asks
+type scraperService struct {
+	lifecycle   scraper.Lifecycle
+	command     []string
+	maxRestarts int
+	stopTimeout time.Duration
+	process     *supervisor.Process // nil unless this run started the scraper
+}
+
+// newScraperService creates the lifecycle handling for the configured scraper
+func newScraperService(cfg *Config) *scraperService {
+	return &scraperService{
+		lifecycle:   cfg.ScraperLifecycle,
+		command:     supervisor.ParseCommand(cfg.ScraperCommand),
+		maxRestarts: cfg.ScraperMaxRestarts,
+		stopTimeout: cfg.ScraperStopTimeout,
+	}
+}
+
+// start runs the scraper command when the lifecycle calls for it: always when managed,
+// and when shared only if no scraper answers yet
+func (s *scraperService) start(ctx context.Context, client scraper.Scraper) error {
+	options := supervisor.Options{StopTimeout: s.stopTimeout}
+	switch s.lifecycle {
+	case scraper.LifecycleManaged:
+		options.Restart = true
+		options.MaxRestarts = s.maxRestarts
+		options.OnExit = func(err error, restart int, wait time.Duration) {
+			if errors.Is(err, supervisor.ErrGaveUp) || wait == 0 {
+				fmt.Printf("⚠️ Scraper process stopped: %v\n", err)
+				return
+			}
+			fmt.Printf("⚠️ Scraper process exited (%v), restarting in %s (%d/%d)\n", err, wait, restart, s.maxRestarts)
+		}
+	case scraper.LifecycleShared:
+		if len(s.command) == 0 {
+			return nil
+		}
+		if _, err := client.Health(ctx); err == nil {
+			fmt.Println("  Scraper already running, it will be left running")
+			return nil
+		}
+		options.OnExit = func(err error, restart int, wait time.Duration) {
+			fmt.Printf("⚠️ Scraper process stopped: %v\n", err)
+		}
+	default:
+		return nil
+	}
+
+	fmt.Printf("Starting scraper: %s\n", strings.Join(s.command, " "))
+	process, err := supervisor.Start(s.command, options)
+	if err != nil {
+		return err
+	}
+	s.process = process
+	return nil
+}
+
+// shutdown tells the scraper to exit when this run owns it: a started process is stopped,
+// and in dedicated mode the service is sent the exit signal
+func (s *scraperService) shutdown(client scraper.Scraper) {
+	if s.process != nil {
+		s.stopProcess()
+		return
+	}
+
+	exiter, ok := client.(scraper.Exiter)
+	if !ok || s.lifecycle != scraper.LifecycleDedicated {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	fmt.Println("Signaling scraper service to exit...")
+	if err := exiter.Exit(ctx); err != nil {
+		fmt.Printf("⚠️ Failed to signal scraper exit: %v\n", err)
+	} else {
+		fmt.Println("✓ Scraper service signaled to exit")
+	}
+}
+
+// stopProcess stops the scraper process started by this run, if any
+func (s *scraperService) stopProcess() {
+	if s.process == nil {
+		return
+	}
+	fmt.Println("Stopping scraper process...")
+	s.process.Stop(context.Background())
+	s.process = nil
+	fmt.Println("✓ Scraper process stopped")
+}
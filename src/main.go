@@ -1,23 +1,45 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
-	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/notion"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/cassette"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/httpfetch"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/platforms"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scrapecache"
 	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
-	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/tables/bookmarks"
 )
 
 func main() {
+	noCache := flag.Bool("no-cache", false, "ignore cached scrape results (fresh results are still cached)")
+	flag.Parse()
+
 	fmt.Println("=== Notion Bookmark Processor ===")
 	fmt.Println()
 
+	// The cache command only touches local files, so it doesn't need the Notion settings
+	if flag.Arg(0) == "cache" {
+		cfg, err := LoadCacheConfig()
+		if err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+		if err := runCacheCommand(cfg, flag.Args()[1:]); err != nil {
+			log.Fatalf("Cache command failed: %v", err)
+		}
+		return
+	}
+	if flag.NArg() > 0 {
+		log.Fatalf("Unknown command %q (expected cache)", flag.Arg(0))
+	}
+
 	// Load configuration
 	cfg, err := Load()
 	if err != nil {
@@ -25,27 +47,38 @@ func main() {
 	}
 
 	// Initialize clients
-	notionClient := notion.NewClient(cfg.NotionAPIKey, cfg.BookmarksDBID, cfg.TagsDBID, cfg.ManualListDBID, cfg.SmartListDBID)
-	bookmarkService := bookmarks.NewService(notionClient)
+	c, err := newClients(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize clients: %v", err)
+	}
+	defer c.Close()
+	scraperClient := c.scraper
+
+	// Initialize scrape cache if enabled
+	var scrapeCache *scrapecache.Cache
+	if cfg.ScrapeCacheEnabled && cfg.CassetteMode != cassette.ModeReplay {
+		scrapeCache = scrapecache.New(cfg.ScrapeCacheDir, cfg.ScrapeCacheTTL)
+		if *noCache {
+			fmt.Printf("✓ Scrape cache: REFRESHING (--no-cache, results still saved to %s)\n", cfg.ScrapeCacheDir)
+		} else {
+			fmt.Printf("✓ Scrape cache: ENABLED (TTL %s, %s)\n", cfg.ScrapeCacheTTL, cfg.ScrapeCacheDir)
+		}
+	} else {
+		fmt.Println("  Scrape cache: disabled")
+	}
 
-	// Default to localhost if not set in config
-	scraperURL := cfg.WebmeatscraperURL
-	if scraperURL == "" {
-		scraperURL = "http://localhost:7878"
+	// Show snapshot status
+	if cfg.SnapshotEnabled {
+		fmt.Println("✓ HTML snapshots: ENABLED")
+	} else {
+		fmt.Println("  HTML snapshots: disabled")
 	}
-	scraperClient := scraper.NewClient(scraperURL)
 
-	// Initialize image uploader if enabled
-	var imageUploader *notion.ImageUploader
-	if cfg.UploadImagesToNotion {
-		imageUploader = notion.NewImageUploader(
-			cfg.NotionAPIKey,
-			cfg.ImageUploadTimeout,
-			cfg.ImageUploadPollInterval,
-		)
-		fmt.Println("✓ Image upload to Notion: ENABLED")
+	// Show platform metadata status
+	if len(cfg.PlatformProperties) > 0 {
+		fmt.Printf("✓ Platform properties: ENABLED (%d mapped)\n", len(cfg.PlatformProperties))
 	} else {
-		fmt.Println("  Image upload to Notion: disabled")
+		fmt.Println("  Platform properties: disabled")
 	}
 
 	// Show debug mode status
@@ -56,21 +89,72 @@ func main() {
 	}
 	fmt.Println()
 
-	ctx := context.Background()
+	// Interrupts cancel ctx, which stops the startup waits and the loop between bookmarks
+	// Bookmarks run with processCtx instead, so the one in flight finishes (an interrupted
+	// content update could leave its page empty) and a started scraper is still shut down
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	processCtx := context.WithoutCancel(ctx)
+	// Once the first interrupt arrives signals are no longer caught, so a second one ends
+	// the run right away instead of waiting for a slow scrape and its retries
+	go func() {
+		<-ctx.Done()
+		stopSignals()
+	}()
+
+	// Start the scraper service when this run is responsible for it
+	service := newScraperService(cfg)
+	if cfg.ScraperBackend == scraper.BackendWebmeatscraper {
+		if err := service.start(ctx, scraperClient); err != nil {
+			log.Fatalf("Failed to start scraper: %v", err)
+		}
+	}
 
-	// Check scraper service health
-	fmt.Printf("Checking scraper service at %s...\n", scraperClient.BaseURL())
-	health, err := scraperClient.Health(ctx)
+	// Wait for the scraper service, which may still be starting up
+	fmt.Printf("Checking scraper (%s)...\n", scraperClient.Name())
+	degraded := false
+	health, err := scraper.WaitReady(ctx, scraperClient, scraper.ReadyOptions{
+		Timeout:    cfg.ScraperReadyTimeout,
+		Backoff:    cfg.ScraperReadyBackoff,
+		MaxBackoff: cfg.ScraperReadyMaxBackoff,
+		OnWait: func(attempt int, err error, wait time.Duration) {
+			fmt.Printf("  ⏳ Scraper not ready (%v), checking again in %s\n", err, wait.Round(100*time.Millisecond))
+		},
+	})
 	if err != nil {
-		log.Fatalf("Scraper service is not available: %v", err)
+		if !cfg.ScraperDegradedMode || cfg.ScraperBackend == scraper.BackendNative {
+			service.stopProcess()
+			log.Fatalf("Scraper service is not available: %v", err)
+		}
+		// Degraded mode: metadata comes from the built-in scraper instead of the service
+		fmt.Printf("⚠️ Scraper service is not available: %v\n", err)
+		service.stopProcess()
+		degraded = true
+		c.scraper = c.newNative()
+		scraperClient = c.scraper
+		fmt.Printf("⚠️ Continuing in degraded mode with the %s\n", scraperClient.Name())
+		if health, err = scraperClient.Health(ctx); err != nil {
+			log.Fatalf("Scraper is not available: %v", err)
+		}
 	}
 	fmt.Printf("✓ Scraper service is healthy (status: %s)\n", health.Status)
+	if client, ok := scraperClient.(*scraper.Client); ok && len(client.BaseURLs()) > 1 {
+		// Unhealthy instances are skipped for a cooldown, then checked again
+		for _, endpoint := range client.Endpoints() {
+			if endpoint.Healthy {
+				fmt.Printf("  ✓ %s\n", endpoint.BaseURL)
+			} else {
+				fmt.Printf("  ⚠️ %s unavailable: %v\n", endpoint.BaseURL, endpoint.LastError)
+			}
+		}
+	}
 	fmt.Println()
 
 	// Fetch ALL unprocessed bookmarks
 	fmt.Println("Fetching unprocessed bookmarks...")
-	unprocessed, err := bookmarkService.GetUnprocessed(ctx, 0) // 0 = get all
+	unprocessed, err := c.bookmarkService.GetUnprocessed(ctx, 0) // 0 = get all
 	if err != nil {
+		service.stopProcess()
 		log.Fatalf("Failed to fetch unprocessed bookmarks: %v", err)
 	}
 
@@ -78,17 +162,46 @@ func main() {
 		fmt.Println("No unprocessed bookmarks found.")
 		fmt.Println()
 		fmt.Println("=== Processing Complete ===")
-		os.Exit(0)
+		service.stopProcess()
+		return
 	}
 
 	fmt.Printf("Found %d unprocessed bookmark(s)\n", len(unprocessed))
 	fmt.Println()
 
 	// Process each bookmark
+	processor := c.newProcessor(cfg)
+	if scrapeCache != nil {
+		processor.SetScrapeCache(scrapeCache, *noCache)
+	}
+	if degraded {
+		processor.SetDegraded()
+	}
+	if len(cfg.PlatformProperties) > 0 {
+		properties, missing, err := resolvePlatformProperties(ctx, c.notion, cfg.BookmarksDBID, cfg.PlatformProperties)
+		if err != nil {
+			fmt.Printf("⚠️ Platform properties disabled: %v\n", err)
+		} else {
+			if len(missing) > 0 {
+				fmt.Printf("⚠️ Skipping platform properties missing from the bookmarks database: %s\n", strings.Join(missing, ", "))
+			}
+			// Platform API calls are small JSON documents, fetched under the same URL policy
+			platformFetch := c.fetch
+			platformFetch.Timeout = cfg.ImageProbeTimeout
+			fetcher := httpfetch.NewWithOptions(platformFetch)
+			extractor := platforms.NewExtractor(fetcher, platforms.Options{GitHubToken: cfg.GitHubToken})
+			processor.SetPlatformProperties(extractor, properties)
+		}
+		fmt.Println()
+	}
 	successCount := 0
 	errorCount := 0
 
 	for i, bookmark := range unprocessed {
+		if ctx.Err() != nil {
+			fmt.Printf("Interrupted, skipping the remaining %d bookmark(s)\n", len(unprocessed)-i)
+			break
+		}
 		fmt.Printf("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 		fmt.Printf("Processing bookmark %d of %d\n", i+1, len(unprocessed))
 		fmt.Printf("Title: %s\n", bookmark.Title)
@@ -96,190 +209,12 @@ func main() {
 		fmt.Printf("URL: %s\n", bookmark.URL)
 		fmt.Println()
 
-		// Scrape the bookmark
-		fmt.Println("Scraping content...")
-		result, err := scraperClient.Scrape(ctx, bookmark.URL)
-		if err != nil {
-			// On error: Set error field and mark as not processed
-			errorMsg := fmt.Sprintf("Failed to scrape URL: %v", err)
-			fmt.Printf("✗ %s\n", errorMsg)
-			fmt.Println()
-			fmt.Println("Updating bookmark with error...")
-
-			_, updateErr := bookmarkService.SetError(ctx, bookmark.ID, errorMsg)
-			if updateErr != nil {
-				log.Printf("Failed to update bookmark with error: %v", updateErr)
-				errorCount++
-				fmt.Println()
-				continue
-			}
-
-			fmt.Println("✓ Bookmark marked with error")
-			fmt.Println()
-			errorCount++
-			continue
-		}
-
-		// Print full raw JSON response (only if DEBUG is enabled)
-		if cfg.Debug {
-			fmt.Println("=== SCRAPED CONTENT (Full JSON) ===")
-			// Pretty print the raw JSON
-			var prettyJSON bytes.Buffer
-			if err := json.Indent(&prettyJSON, []byte(result.RawJSON), "", "  "); err == nil {
-				fmt.Println(prettyJSON.String())
-			} else {
-				// Fallback to raw JSON if indentation fails
-				fmt.Println(result.RawJSON)
-			}
-			fmt.Println("=== END OF SCRAPED CONTENT ===")
-			fmt.Println()
-		}
-
-		// Use the parsed content
-		content := result.Content
-
-		// Update bookmark with scraped metadata
-		fmt.Println("Updating bookmark with scraped metadata...")
-		updated := false
-
-		// Update Author if empty and available
-		if bookmark.Author == "" && content.Metadata != nil && content.Metadata.Author != "" {
-			bookmark.Author = content.Metadata.Author
-			fmt.Printf("  ✓ Set author: %s\n", content.Metadata.Author)
-			updated = true
-		}
-
-		// Extract image URL from scraped content (try multiple sources)
-		var imageURL string
-
-		// Priority order: content.Image → metadata.Image
-		if content.Image != nil && *content.Image != "" {
-			imageURL = *content.Image
-		} else if content.Metadata != nil && content.Metadata.Image != nil && *content.Metadata.Image != "" {
-			imageURL = *content.Metadata.Image
-		}
-
-		// Extract favicon URL from scraped content
-		var faviconURL string
-		if content.Metadata != nil && content.Metadata.Logo != nil && *content.Metadata.Logo != "" {
-			faviconURL = *content.Metadata.Logo
-		}
-
-		// Upload image to Notion and set as page cover (if enabled)
-		var coverFileUploadID string
-		if imageURL != "" && cfg.UploadImagesToNotion && imageUploader != nil {
-			fmt.Printf("  📤 Uploading image to Notion...")
-
-			fileUploadID, err := imageUploader.UploadImageFromURL(ctx, imageURL)
-			if err != nil {
-				if cfg.FallbackToExternalURL {
-					fmt.Printf(" ⚠️  Upload failed (%v), using external URL\n", err)
-					// Keep imageURL as-is for database property
-				} else {
-					fmt.Printf(" ❌ Upload failed: %v\n", err)
-					imageURL = "" // Don't set any image
-				}
-			} else {
-				fmt.Printf(" ✅ Uploaded (ID: %s)\n", fileUploadID)
-				coverFileUploadID = fileUploadID
-			}
-		}
-
-		// Upload favicon to Notion and set as page icon
-		var iconFileUploadID string
-		if faviconURL != "" && cfg.UploadImagesToNotion && imageUploader != nil {
-			fmt.Printf("  📤 Uploading favicon to Notion...")
-
-			fileUploadID, err := imageUploader.UploadImageFromURL(ctx, faviconURL)
-			if err != nil {
-				fmt.Printf(" ❌ Upload failed: %v\n", err)
-				faviconURL = "" // Don't set any icon
-			} else {
-				fmt.Printf(" ✅ Uploaded (ID: %s)\n", fileUploadID)
-				iconFileUploadID = fileUploadID
-			}
-		}
-
-		// Update ImageURL property if empty and available
-		if bookmark.ImageURL == "" && imageURL != "" {
-			bookmark.ImageURL = imageURL
-			fmt.Printf("  ✓ Set image property: %s\n", imageURL)
-			updated = true
-		}
-
-		// Set date processed and full JSON
-		bookmark.DateProcessed = time.Now()
-
-		// Mark as processed and clear error
-		bookmark.Processed = true
-		bookmark.Error = ""
-
-		// Update the bookmark in Notion
-		_, err = bookmarkService.Update(ctx, bookmark.ID, bookmark)
-		if err != nil {
-			log.Printf("Failed to update bookmark: %v", err)
+		if err := processor.Process(processCtx, bookmark); err != nil {
 			errorCount++
 			fmt.Println()
 			continue
 		}
 
-		if updated {
-			fmt.Println("✓ Bookmark metadata updated")
-		} else {
-			fmt.Println("  (No metadata property updates needed)")
-		}
-
-		// Prepare JSON content for page: truncate only the "content" field if present
-		jsonContent := result.RawJSON
-		var jsonData map[string]interface{}
-		if err := json.Unmarshal([]byte(result.RawJSON), &jsonData); err == nil {
-			if content, ok := jsonData["content"].(string); ok && len(content) > 250 {
-				jsonData["content"] = content[:250] + " [truncated]"
-				if modifiedJSON, err := json.Marshal(jsonData); err == nil {
-					jsonContent = string(modifiedJSON)
-				}
-			}
-		}
-
-		// Pretty-print the JSON for better readability in the code block
-		var prettyJSON bytes.Buffer
-		if err := json.Indent(&prettyJSON, []byte(jsonContent), "", "  "); err == nil {
-			jsonContent = prettyJSON.String()
-		}
-
-		// Update page content with full JSON as code block (erase all existing content)
-		fmt.Println("  📝 Updating page content with full JSON...")
-		err = notion.UpdatePageContentWithJSON(ctx, cfg.NotionAPIKey, string(bookmark.ID), jsonContent)
-		if err != nil {
-			fmt.Printf(" ⚠️  Failed to update page content: %v\n", err)
-			// Don't fail the whole process, just log the warning
-		} else {
-			fmt.Printf(" ✅ Page content updated\n")
-		}
-
-		// Set page cover if we have a FileUpload ID (always update cover)
-		if coverFileUploadID != "" {
-			fmt.Printf("  🖼️  Setting page cover...")
-			err = notion.SetPageCover(ctx, cfg.NotionAPIKey, string(bookmark.ID), coverFileUploadID)
-			if err != nil {
-				fmt.Printf(" ⚠️  Failed to set cover: %v\n", err)
-			} else {
-				fmt.Printf(" ✅ Cover set\n")
-			}
-		}
-
-		// Set page icon if we have a FileUpload ID
-		if iconFileUploadID != "" {
-			fmt.Printf("  🖼️  Setting page icon...")
-			err = notion.SetPageIcon(ctx, cfg.NotionAPIKey, string(bookmark.ID), iconFileUploadID)
-			if err != nil {
-				fmt.Printf(" ⚠️  Failed to set icon: %v\n", err)
-			} else {
-				fmt.Printf(" ✅ Icon set\n")
-			}
-		}
-
-		fmt.Println("✓ Bookmark marked as processed")
 		fmt.Println()
 		successCount++
 	}
@@ -292,12 +227,13 @@ func main() {
 	fmt.Printf("✗ Failed: %d\n", errorCount)
 	fmt.Println()
 
-	// Signal the scraper service to exit
-	fmt.Println("Signaling scraper service to exit...")
-	err = scraperClient.Exit(ctx)
-	if err != nil {
-		fmt.Printf("⚠️ Failed to signal scraper exit: %v\n", err)
-	} else {
-		fmt.Println("✓ Scraper service signaled to exit")
+	// Stop the scraper service if this run owns it (the built-in scraper has nothing to stop)
+	service.shutdown(scraperClient)
+
+	// Unused interactions mean this run diverged from the recorded one
+	if c.replayer != nil {
+		if remaining := c.replayer.Remaining(); remaining > 0 {
+			fmt.Printf("⚠️ %d recorded interaction(s) were not replayed\n", remaining)
+		}
 	}
 }
@@ -0,0 +1,110 @@
+package cassette
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Mode selects whether HTTP traffic is recorded, replayed or passed through
+type Mode string
+
+const (
+	// ModeOff sends requests to the network without recording them
+	ModeOff Mode = "off"
+	// ModeRecord sends requests to the network and appends every exchange to the cassette
+	ModeRecord Mode = "record"
+	// ModeReplay answers requests from the cassette without touching the network
+	ModeReplay Mode = "replay"
+)
+
+// ParseMode parses a cassette mode name
+func ParseMode(value string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case ModeOff, ModeRecord, ModeReplay:
+		return mode, nil
+	case "":
+		return ModeOff, nil
+	default:
+		return "", fmt.Errorf("unknown cassette mode %q (expected off, record or replay)", value)
+	}
+}
+
+// redacted replaces secrets and credential headers in recorded traffic
+const redacted = "[REDACTED]"
+
+// Interaction is one recorded HTTP exchange
+// Bodies that aren't valid UTF-8 are stored base64-encoded
+type Interaction struct {
+	Request    Request       `json:"request"`
+	Response   Response      `json:"response"`
+	RecordedAt time.Time     `json:"recorded_at"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// Request is the recorded side of an outgoing request
+type Request struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 bool        `json:"body_base64,omitempty"`
+}
+
+// Response is the recorded answer to a request
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 bool        `json:"body_base64,omitempty"`
+}
+
+// Load reads a cassette file (one JSON interaction per line)
+func Load(path string) ([]*Interaction, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var interactions []*Interaction
+	scanner := bufio.NewScanner(file)
+	// Lines hold whole response bodies, so allow far more than the 64KB default
+	scanner.Buffer(make([]byte, 0, 1024*1024), 256*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(scanner.Bytes(), &interaction); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		interactions = append(interactions, &interaction)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return interactions, nil
+}
+
+// encodeBody stores a body as text when possible, base64 otherwise
+func encodeBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+	return base64.StdEncoding.EncodeToString(body), true
+}
+
+// decodeBody reverses encodeBody
+func decodeBody(body string, isBase64 bool) ([]byte, error) {
+	if !isBase64 {
+		return []byte(body), nil
+	}
+	return base64.StdEncoding.DecodeString(body)
+}
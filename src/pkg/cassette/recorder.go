@@ -0,0 +1,177 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sensitiveHeaders are replaced in recorded requests and responses
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// RecorderOptions holds the optional settings of a Recorder
+type RecorderOptions struct {
+	// Transport sends the requests (nil = http.DefaultTransport)
+	Transport http.RoundTripper
+	// Secrets are replaced wherever they appear in URLs, headers and bodies (e.g. API keys)
+	Secrets []string
+}
+
+// Recorder is an http.RoundTripper that sends requests and appends each exchange to a
+// cassette file as it completes, so a run that stops early still leaves a usable cassette
+type Recorder struct {
+	transport http.RoundTripper
+	replacer  *strings.Replacer
+	out       *cassetteFile
+}
+
+// cassetteFile is the cassette shared by a recorder and the copies made by WithTransport
+type cassetteFile struct {
+	mu      sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+	count   int
+}
+
+// NewRecorder creates the cassette file, replacing an existing one
+func NewRecorder(path string, options RecorderOptions) (*Recorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cassette: %w", err)
+	}
+
+	transport := options.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	var pairs []string
+	for _, secret := range options.Secrets {
+		if secret != "" {
+			pairs = append(pairs, secret, redacted)
+		}
+	}
+
+	return &Recorder{
+		transport: transport,
+		replacer:  strings.NewReplacer(pairs...),
+		out:       &cassetteFile{file: file, encoder: json.NewEncoder(file)},
+	}, nil
+}
+
+// WithTransport returns a recorder that sends requests through another transport
+// (e.g. one enforcing a URL policy) and appends them to the same cassette
+func (r *Recorder) WithTransport(transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{transport: transport, replacer: r.replacer, out: r.out}
+}
+
+// RoundTrip sends the request and records the exchange
+// Transport errors are returned as-is and not recorded
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, fmt.Errorf("cassette: failed to read request body: %w", err)
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	started := time.Now()
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("cassette: failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := &Interaction{
+		Request: Request{
+			Method: req.Method,
+			URL:    r.replacer.Replace(req.URL.String()),
+			Header: r.redactHeader(req.Header),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     r.redactHeader(resp.Header),
+		},
+		RecordedAt: started.UTC(),
+		Duration:   time.Since(started),
+	}
+	interaction.Request.Body, interaction.Request.BodyBase64 = r.redactBody(reqBody)
+	interaction.Response.Body, interaction.Response.BodyBase64 = r.redactBody(respBody)
+
+	if err := r.write(interaction); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Len returns the number of exchanges recorded so far
+func (r *Recorder) Len() int {
+	r.out.mu.Lock()
+	defer r.out.mu.Unlock()
+	return r.out.count
+}
+
+// Close closes the cassette file
+func (r *Recorder) Close() error {
+	r.out.mu.Lock()
+	defer r.out.mu.Unlock()
+	return r.out.file.Close()
+}
+
+// write appends an interaction to the cassette
+func (r *Recorder) write(interaction *Interaction) error {
+	r.out.mu.Lock()
+	defer r.out.mu.Unlock()
+	if err := r.out.encoder.Encode(interaction); err != nil {
+		return fmt.Errorf("cassette: failed to write interaction: %w", err)
+	}
+	r.out.count++
+	return nil
+}
+
+// redactHeader copies a header with credentials and secrets replaced
+func (r *Recorder) redactHeader(header http.Header) http.Header {
+	clean := make(http.Header, len(header))
+	for name, values := range header {
+		for _, value := range values {
+			clean.Add(name, r.replacer.Replace(value))
+		}
+	}
+	for _, name := range sensitiveHeaders {
+		if clean.Get(name) != "" {
+			clean.Set(name, redacted)
+		}
+	}
+	return clean
+}
+
+// redactBody replaces secrets in text bodies; binary bodies are stored unchanged
+func (r *Recorder) redactBody(body []byte) (string, bool) {
+	text, isBase64 := encodeBody(body)
+	if isBase64 {
+		return text, true
+	}
+	return r.replacer.Replace(text), false
+}
@@ -0,0 +1,188 @@
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrNoInteraction is returned when a cassette has no unused exchange for a request
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// Replayer is an http.RoundTripper that answers requests from recorded interactions
+// Requests are matched on method and URL. When several recorded requests share them, the
+// one with the same body is preferred, so concurrent requests to one endpoint can't swap
+// responses; otherwise (e.g. a body holding a timestamp) they are answered in recorded order
+type Replayer struct {
+	mu      sync.Mutex
+	pending map[string][]*pendingInteraction
+}
+
+// pendingInteraction is a recorded interaction not replayed yet
+type pendingInteraction struct {
+	interaction *Interaction
+	digest      string
+}
+
+// NewReplayer creates a replayer for recorded interactions
+func NewReplayer(interactions []*Interaction) (*Replayer, error) {
+	pending := make(map[string][]*pendingInteraction)
+	for _, interaction := range interactions {
+		key := replayKey(interaction.Request.Method, interaction.Request.URL)
+		body, err := decodeBody(interaction.Request.Body, interaction.Request.BodyBase64)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: invalid request body for %s: %w", key, err)
+		}
+		pending[key] = append(pending[key], &pendingInteraction{
+			interaction: interaction,
+			digest:      requestDigest(interaction.Request.Method, interaction.Request.Header, body),
+		})
+	}
+	return &Replayer{pending: pending}, nil
+}
+
+// LoadReplayer reads a cassette file and creates a replayer for it
+func LoadReplayer(path string) (*Replayer, error) {
+	interactions, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(interactions)
+}
+
+// RoundTrip returns the next recorded response for the request
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cassette: failed to read request body: %w", err)
+		}
+	}
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	key := replayKey(req.Method, req.URL.String())
+	digest := requestDigest(req.Method, req.Header, reqBody)
+	r.mu.Lock()
+	queue := r.pending[key]
+	if len(queue) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("cassette: %w for %s", ErrNoInteraction, key)
+	}
+	next := 0
+	for i, candidate := range queue {
+		if candidate.digest == digest {
+			next = i
+			break
+		}
+	}
+	interaction := queue[next].interaction
+	r.pending[key] = slices.Delete(queue, next, next+1)
+	r.mu.Unlock()
+
+	body, err := decodeBody(interaction.Response.Body, interaction.Response.BodyBase64)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: invalid response body for %s: %w", key, err)
+	}
+
+	header := interaction.Response.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	// The body is stored decoded, so its original encoding and length no longer apply
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+		StatusCode:    interaction.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Remaining returns the number of recorded interactions not replayed yet
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	remaining := 0
+	for _, queue := range r.pending {
+		remaining += len(queue)
+	}
+	return remaining
+}
+
+// replayKey identifies the requests an interaction can answer
+func replayKey(method, url string) string {
+	return method + " " + url
+}
+
+// requestDigest hashes the body of a POST, PUT or PATCH request in a form that is stable
+// between runs: JSON is compared without formatting or key order, and multipart bodies part
+// by part since their boundary is random
+func requestDigest(method string, header http.Header, body []byte) string {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return ""
+	}
+	if len(body) == 0 {
+		return ""
+	}
+
+	hash := sha256.New()
+	mediaType, params, _ := mime.ParseMediaType(header.Get("Content-Type"))
+
+	var value any
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "":
+		if err := hashMultipart(hash, body, params["boundary"]); err != nil {
+			hash.Reset()
+			hash.Write(body)
+		}
+	case json.Unmarshal(body, &value) == nil:
+		// Map keys are sorted when marshalled
+		normalized, _ := json.Marshal(value)
+		hash.Write(normalized)
+	default:
+		hash.Write(body)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// hashMultipart writes the headers and content of every part to hash
+func hashMultipart(hash io.Writer, body []byte, boundary string) error {
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%q %q %q\n", part.FormName(), part.FileName(), part.Header.Get("Content-Type"))
+		if _, err := io.Copy(hash, part); err != nil {
+			return err
+		}
+	}
+}
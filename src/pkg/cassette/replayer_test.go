@@ -0,0 +1,89 @@
+package cassette_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/cassette"
+)
+
+// interaction records a JSON POST to the upload endpoint answered with answer
+func interaction(body, answer string) *cassette.Interaction {
+	return &cassette.Interaction{
+		Request: cassette.Request{
+			Method: http.MethodPost,
+			URL:    "https://api.notion.com/v1/file_uploads",
+			Header: http.Header{"Content-Type": {"application/json"}},
+			Body:   body,
+		},
+		Response: cassette.Response{StatusCode: http.StatusOK, Body: answer},
+	}
+}
+
+// replay sends a JSON POST to the upload endpoint and returns the replayed body
+func replay(t *testing.T, replayer *cassette.Replayer, body string) string {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, "https://api.notion.com/v1/file_uploads", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := replayer.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip(%s): %v", body, err)
+	}
+	defer resp.Body.Close()
+	answer, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(answer)
+}
+
+func TestReplayerPrefersMatchingBody(t *testing.T) {
+	replayer, err := cassette.NewReplayer([]*cassette.Interaction{
+		interaction(`{"filename": "cover.png", "mode": "single_part"}`, "cover"),
+		interaction(`{"filename": "icon.png", "mode": "single_part"}`, "icon"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Concurrent uploads can arrive in either order; formatting and key order don't matter
+	if got := replay(t, replayer, `{"mode":"single_part","filename":"icon.png"}`); got != "icon" {
+		t.Errorf("icon upload got %q", got)
+	}
+	if got := replay(t, replayer, `{"filename":"cover.png","mode":"single_part"}`); got != "cover" {
+		t.Errorf("cover upload got %q", got)
+	}
+	if remaining := replayer.Remaining(); remaining != 0 {
+		t.Errorf("Remaining() = %d, want 0", remaining)
+	}
+}
+
+func TestReplayerFallsBackToRecordedOrder(t *testing.T) {
+	replayer, err := cassette.NewReplayer([]*cassette.Interaction{
+		interaction(`{"recorded_at": "2026-10-18T09:00:00Z"}`, "first"),
+		interaction(`{"recorded_at": "2026-10-18T09:00:01Z"}`, "second"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Bodies that differ between runs, e.g. timestamps, are answered in recorded order
+	for _, want := range []string{"first", "second"} {
+		if got := replay(t, replayer, `{"recorded_at": "2026-10-19T10:00:00Z"}`); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+	req, err := http.NewRequest(http.MethodPost, "https://api.notion.com/v1/file_uploads", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := replayer.RoundTrip(req); !errors.Is(err, cassette.ErrNoInteraction) {
+		t.Errorf("RoundTrip after the cassette ran out = %v, want ErrNoInteraction", err)
+	}
+}
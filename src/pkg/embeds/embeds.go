@@ -0,0 +1,218 @@
+package embeds
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/notion"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+)
+
+// Platform identifies a site with a richer preview than a static cover image
+type Platform string
+
+const (
+	PlatformYouTube     Platform = "youtube"
+	PlatformVimeo       Platform = "vimeo"
+	PlatformLoom        Platform = "loom"
+	PlatformTwitter     Platform = "twitter"
+	PlatformGist        Platform = "gist"
+	PlatformCodePen     Platform = "codepen"
+	PlatformCodeSandbox Platform = "codesandbox"
+	PlatformSpotify     Platform = "spotify"
+	PlatformSoundCloud  Platform = "soundcloud"
+	PlatformFigma       Platform = "figma"
+	PlatformGitHub      Platform = "github"
+	PlatformGitLab      Platform = "gitlab"
+)
+
+// Kind is the Notion block used to present a platform link
+type Kind string
+
+const (
+	// KindVideo uses a video block (Notion plays YouTube and Vimeo inline)
+	KindVideo Kind = "video"
+	// KindEmbed uses an embed block (Notion renders an iframe preview)
+	KindEmbed Kind = "embed"
+	// KindBookmark uses a bookmark block (link card with title and description)
+	KindBookmark Kind = "bookmark"
+)
+
+// Embed describes the block to insert for a detected platform link
+type Embed struct {
+	Platform Platform
+	Kind     Kind
+	URL      string
+}
+
+// Block returns the Notion block for the embed
+func (e *Embed) Block() notion.Block {
+	switch e.Kind {
+	case KindVideo:
+		return notion.NewVideo(notion.ExternalSource(e.URL))
+	case KindEmbed:
+		return notion.NewEmbed(e.URL)
+	default:
+		return notion.NewBookmark(e.URL)
+	}
+}
+
+// matcher detects a platform from a parsed URL and returns the canonical URL to embed
+type matcher struct {
+	platform Platform
+	kind     Kind
+	hosts    []string
+	match    func(u *url.URL) (string, bool)
+}
+
+var (
+	youtubeIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	numericPattern   = regexp.MustCompile(`^[0-9]+$`)
+)
+
+var matchers = []matcher{
+	{platform: PlatformYouTube, kind: KindVideo, hosts: []string{"youtube.com", "youtu.be", "youtube-nocookie.com"}, match: matchYouTube},
+	{platform: PlatformVimeo, kind: KindVideo, hosts: []string{"vimeo.com"}, match: matchVimeo},
+	{platform: PlatformLoom, kind: KindEmbed, hosts: []string{"loom.com"}, match: pathPrefix("/share/", "/embed/")},
+	{platform: PlatformTwitter, kind: KindEmbed, hosts: []string{"twitter.com", "x.com"}, match: matchTweet},
+	{platform: PlatformGist, kind: KindEmbed, hosts: []string{"gist.github.com"}, match: minSegments(2)},
+	{platform: PlatformCodePen, kind: KindEmbed, hosts: []string{"codepen.io"}, match: matchCodePen},
+	{platform: PlatformCodeSandbox, kind: KindEmbed, hosts: []string{"codesandbox.io"}, match: pathPrefix("/s/", "/p/", "/embed/")},
+	{platform: PlatformSpotify, kind: KindEmbed, hosts: []string{"open.spotify.com"}, match: minSegments(2)},
+	{platform: PlatformSoundCloud, kind: KindEmbed, hosts: []string{"soundcloud.com"}, match: minSegments(2)},
+	{platform: PlatformFigma, kind: KindEmbed, hosts: []string{"figma.com"}, match: pathPrefix("/file/", "/design/", "/proto/", "/board/")},
+	{platform: PlatformGitHub, kind: KindBookmark, hosts: []string{"github.com"}, match: minSegments(2)},
+	{platform: PlatformGitLab, kind: KindBookmark, hosts: []string{"gitlab.com"}, match: minSegments(2)},
+}
+
+// Detect finds a platform embed for a bookmark URL
+// The canonical URL from the scraped metadata is tried as well, which resolves
+// shortened or redirected links (e.g. t.co or feed proxies)
+func Detect(pageURL string, meta *scraper.Metadata) (*Embed, bool) {
+	candidates := []string{pageURL}
+	if meta != nil && meta.URL != "" && meta.URL != pageURL {
+		candidates = append(candidates, meta.URL)
+	}
+
+	for _, candidate := range candidates {
+		parsed, err := url.Parse(strings.TrimSpace(candidate))
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			continue
+		}
+
+		host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+		host = strings.TrimPrefix(host, "m.")
+
+		for _, m := range matchers {
+			if !hostMatches(host, m.hosts) {
+				continue
+			}
+			if embedURL, ok := m.match(parsed); ok {
+				return &Embed{Platform: m.platform, Kind: m.kind, URL: embedURL}, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// hostMatches reports whether host equals one of the hosts (subdomains are not matched
+// unless listed, so e.g. docs.github.com is not treated as a repository)
+func hostMatches(host string, hosts []string) bool {
+	for _, h := range hosts {
+		if host == h {
+			return true
+		}
+	}
+	return false
+}
+
+// segments returns the non-empty path segments of a URL
+func segments(u *url.URL) []string {
+	var result []string
+	for _, s := range strings.Split(u.Path, "/") {
+		if s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// cleanURL drops query strings and fragments that only carry tracking parameters
+func cleanURL(u *url.URL) string {
+	cleaned := *u
+	cleaned.RawQuery = ""
+	cleaned.Fragment = ""
+	cleaned.Scheme = "https"
+	return cleaned.String()
+}
+
+// matchYouTube normalizes watch, short-link, shorts and embed URLs to a watch URL
+func matchYouTube(u *url.URL) (string, bool) {
+	var id string
+	parts := segments(u)
+
+	switch {
+	case strings.HasSuffix(u.Hostname(), "youtu.be") && len(parts) >= 1:
+		id = parts[0]
+	case len(parts) >= 1 && parts[0] == "watch":
+		id = u.Query().Get("v")
+	case len(parts) >= 2 && (parts[0] == "shorts" || parts[0] == "embed" || parts[0] == "live" || parts[0] == "v"):
+		id = parts[1]
+	}
+
+	if !youtubeIDPattern.MatchString(id) {
+		return "", false
+	}
+	return "https://www.youtube.com/watch?v=" + id, true
+}
+
+// matchVimeo matches vimeo.com/<id> and vimeo.com/channels/<name>/<id>
+func matchVimeo(u *url.URL) (string, bool) {
+	parts := segments(u)
+	if len(parts) == 0 || !numericPattern.MatchString(parts[len(parts)-1]) {
+		return "", false
+	}
+	return "https://vimeo.com/" + parts[len(parts)-1], true
+}
+
+// matchTweet matches <host>/<user>/status/<id>
+func matchTweet(u *url.URL) (string, bool) {
+	parts := segments(u)
+	if len(parts) < 3 || parts[1] != "status" || !numericPattern.MatchString(parts[2]) {
+		return "", false
+	}
+	return "https://twitter.com/" + parts[0] + "/status/" + parts[2], true
+}
+
+// matchCodePen matches codepen.io/<user>/pen/<id>
+func matchCodePen(u *url.URL) (string, bool) {
+	parts := segments(u)
+	if len(parts) < 3 || (parts[1] != "pen" && parts[1] != "full" && parts[1] != "details") {
+		return "", false
+	}
+	return "https://codepen.io/" + parts[0] + "/pen/" + parts[2], true
+}
+
+// pathPrefix matches URLs whose path starts with one of the prefixes
+func pathPrefix(prefixes ...string) func(u *url.URL) (string, bool) {
+	return func(u *url.URL) (string, bool) {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(u.Path, prefix) && len(u.Path) > len(prefix) {
+				return cleanURL(u), true
+			}
+		}
+		return "", false
+	}
+}
+
+// minSegments matches URLs with at least n path segments
+func minSegments(n int) func(u *url.URL) (string, bool) {
+	return func(u *url.URL) (string, bool) {
+		if len(segments(u)) < n {
+			return "", false
+		}
+		return cleanURL(u), true
+	}
+}
@@ -0,0 +1,127 @@
+package fakescraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/textutil"
+)
+
+// Fixture is a canned scraper response for the URLs matching a pattern
+type Fixture struct {
+	// Name identifies the fixture in logs (the file name when loaded from a directory)
+	Name string
+	// Match is a glob over the full URL ("*" matches any run of characters, including "/")
+	// or, with a "re:" prefix, a regular expression
+	Match string
+	// Status is the HTTP status to answer with (0 = 200)
+	Status int
+	// Body is sent instead of Response when Status is not 200
+	Body string
+	// Delay is added before answering, replacing the server latency (0 = server latency)
+	Delay time.Duration
+	// Response is the ScrapedContent JSON returned for a successful scrape
+	Response json.RawMessage
+
+	pattern *regexp.Regexp
+}
+
+// fixtureFile is the JSON layout of a fixture file
+type fixtureFile struct {
+	Match    string          `json:"match"`
+	Status   int             `json:"status,omitempty"`
+	Body     string          `json:"body,omitempty"`
+	Delay    string          `json:"delay,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// NewFixture creates a fixture answering URLs that match the pattern with the response
+func NewFixture(match string, response json.RawMessage) (*Fixture, error) {
+	fixture := &Fixture{Name: match, Match: match, Response: response}
+	if err := fixture.compile(); err != nil {
+		return nil, err
+	}
+	return fixture, nil
+}
+
+// LoadFixtures reads every *.json file in a directory, in file name order
+// The first fixture whose pattern matches a URL answers it
+func LoadFixtures(dir string) ([]*Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	fixtures := make([]*Fixture, 0, len(paths))
+	for _, path := range paths {
+		fixture, err := loadFixture(path)
+		if err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", filepath.Base(path), err)
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	return fixtures, nil
+}
+
+// loadFixture reads and validates a single fixture file
+func loadFixture(path string) (*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file fixtureFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse: %w", err)
+	}
+
+	fixture := &Fixture{
+		Name:     filepath.Base(path),
+		Match:    file.Match,
+		Status:   file.Status,
+		Body:     file.Body,
+		Response: file.Response,
+	}
+	if file.Delay != "" {
+		if fixture.Delay, err = time.ParseDuration(file.Delay); err != nil {
+			return nil, fmt.Errorf("invalid delay %q: %w", file.Delay, err)
+		}
+	}
+	if err := fixture.compile(); err != nil {
+		return nil, err
+	}
+	return fixture, nil
+}
+
+// compile checks the fixture and builds its URL pattern
+func (f *Fixture) compile() error {
+	if f.Match == "" {
+		return fmt.Errorf("match pattern is required")
+	}
+	if (f.Status == 0 || f.Status == 200) && len(f.Response) == 0 {
+		return fmt.Errorf("response is required for a successful scrape")
+	}
+
+	expr, ok := strings.CutPrefix(f.Match, "re:")
+	if !ok {
+		expr = textutil.GlobToRegexp(f.Match)
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid match pattern %q: %w", f.Match, err)
+	}
+	f.pattern = pattern
+	return nil
+}
+
+// Matches reports whether the fixture answers a URL
+func (f *Fixture) Matches(url string) bool {
+	return f.pattern.MatchString(url)
+}
@@ -0,0 +1,211 @@
+package fakescraper
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+)
+
+// Options holds the optional settings of a Server
+type Options struct {
+	// Latency is added before every scrape answer (0 = answer immediately)
+	Latency time.Duration
+	// Jitter adds a random extra delay of up to this much to the latency
+	Jitter time.Duration
+	// ErrorRate is the fraction of scrapes (0 to 1) that fail with ErrorStatus
+	ErrorRate float64
+	// ErrorStatus is the status of injected failures (0 = 503)
+	ErrorStatus int
+	// Strict answers 404 for URLs no fixture matches instead of generating a page
+	Strict bool
+	// Logf receives one line per request (nil = no logging)
+	Logf func(format string, args ...interface{})
+}
+
+// Server is a stand-in for webmeatscraper implementing /scrape, /health and /exit
+// It is an http.Handler, so tests can run it with httptest.NewServer
+type Server struct {
+	fixtures    []*Fixture
+	latency     time.Duration
+	jitter      time.Duration
+	errorRate   float64
+	errorStatus int
+	strict      bool
+	logf        func(format string, args ...interface{})
+	started     time.Time
+
+	mu       sync.Mutex
+	scrapes  []string
+	exitOnce sync.Once
+	exited   chan struct{}
+}
+
+// New creates a fake scraper serving the fixtures
+func New(fixtures []*Fixture, options Options) *Server {
+	errorStatus := options.ErrorStatus
+	if errorStatus == 0 {
+		errorStatus = http.StatusServiceUnavailable
+	}
+	return &Server{
+		fixtures:    fixtures,
+		latency:     options.Latency,
+		jitter:      options.Jitter,
+		errorRate:   options.ErrorRate,
+		errorStatus: errorStatus,
+		strict:      options.Strict,
+		logf:        options.Logf,
+		started:     time.Now(),
+		exited:      make(chan struct{}),
+	}
+}
+
+// ServeHTTP routes the scraper endpoints
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/scrape":
+		s.handleScrape(w, r)
+	case "/health":
+		s.handleHealth(w, r)
+	case "/exit":
+		s.handleExit(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// Scrapes returns the URLs scraped so far, in request order
+func (s *Server) Scrapes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.scrapes...)
+}
+
+// Exited is closed once /exit has been called
+func (s *Server) Exited() <-chan struct{} {
+	return s.exited
+}
+
+// handleScrape answers POST /scrape {"url": ...} with the matching fixture
+func (s *Server) handleScrape(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req scraper.ScrapeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.URL == "" {
+		http.Error(w, `expected {"url": "..."}`, http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.scrapes = append(s.scrapes, req.URL)
+	s.mu.Unlock()
+
+	fixture := s.match(req.URL)
+	delay := s.latency
+	if s.jitter > 0 {
+		delay += rand.N(s.jitter + 1)
+	}
+	if fixture != nil && fixture.Delay > 0 {
+		delay = fixture.Delay
+	}
+
+	select {
+	case <-time.After(delay):
+	case <-r.Context().Done():
+		return
+	}
+
+	if s.errorRate > 0 && rand.Float64() < s.errorRate {
+		s.log("scrape %s -> %d (injected)", req.URL, s.errorStatus)
+		http.Error(w, "injected failure", s.errorStatus)
+		return
+	}
+
+	var response json.RawMessage
+	switch {
+	case fixture != nil && fixture.Status != 0 && fixture.Status != http.StatusOK:
+		s.log("scrape %s -> %d (%s)", req.URL, fixture.Status, fixture.Name)
+		http.Error(w, fixture.Body, fixture.Status)
+		return
+	case fixture != nil:
+		s.log("scrape %s -> %s", req.URL, fixture.Name)
+		response = fixture.Response
+	case s.strict:
+		s.log("scrape %s -> 404 (no fixture)", req.URL)
+		http.Error(w, "no fixture matches "+req.URL, http.StatusNotFound)
+		return
+	default:
+		s.log("scrape %s -> generated", req.URL)
+		generated, err := json.Marshal(generatePage(req.URL))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		response = generated
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(response)
+}
+
+// handleHealth answers GET /health
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scraper.HealthResponse{
+		Status:  "ok",
+		Version: "fake",
+		Uptime:  time.Since(s.started).Round(time.Second).String(),
+	})
+}
+
+// handleExit answers GET /exit and closes Exited
+func (s *Server) handleExit(w http.ResponseWriter, r *http.Request) {
+	s.log("exit requested")
+	w.WriteHeader(http.StatusOK)
+	s.exitOnce.Do(func() { close(s.exited) })
+}
+
+// match returns the first fixture matching a URL, or nil
+func (s *Server) match(url string) *Fixture {
+	for _, fixture := range s.fixtures {
+		if fixture.Matches(url) {
+			return fixture
+		}
+	}
+	return nil
+}
+
+// log writes a request line when logging is enabled
+func (s *Server) log(format string, args ...interface{}) {
+	if s.logf != nil {
+		s.logf(format, args...)
+	}
+}
+
+// generatePage builds a plausible page for URLs without a fixture
+func generatePage(rawURL string) *scraper.ScrapedContent {
+	title := rawURL
+	publisher := ""
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		publisher = strings.TrimPrefix(u.Hostname(), "www.")
+		title = publisher + strings.TrimSuffix(u.Path, "/")
+	}
+	return &scraper.ScrapedContent{
+		Content: fmt.Sprintf("# %s\n\nGenerated by the fake scraper for %s.", title, rawURL),
+		Metadata: &scraper.Metadata{
+			Title:       title,
+			Description: "Fake scrape of " + rawURL,
+			Publisher:   publisher,
+			URL:         rawURL,
+		},
+	}
+}
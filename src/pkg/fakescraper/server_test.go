@@ -0,0 +1,81 @@
+package fakescraper_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/fakescraper"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+)
+
+// newClient runs the fake scraper behind a test server and returns a client pointed at it
+func newClient(t *testing.T, server *fakescraper.Server) *scraper.Client {
+	t.Helper()
+	ts := httptest.NewServer(server)
+	t.Cleanup(ts.Close)
+	return scraper.NewClientWithOptions([]string{ts.URL}, scraper.ClientOptions{Timeout: 5 * time.Second})
+}
+
+func TestScrapeMatchesFixture(t *testing.T) {
+	fixture, err := fakescraper.NewFixture("https://example.com/posts/*",
+		json.RawMessage(`{"url": "https://example.com/posts/1", "metadata": {"title": "Fixture title"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := fakescraper.New([]*fakescraper.Fixture{fixture}, fakescraper.Options{Strict: true})
+	client := newClient(t, server)
+
+	result, err := client.Scrape(context.Background(), "https://example.com/posts/1")
+	if err != nil {
+		t.Fatalf("Scrape: %v", err)
+	}
+	if result.Content.Metadata == nil || result.Content.Metadata.Title != "Fixture title" {
+		t.Errorf("metadata = %+v, want the fixture title", result.Content.Metadata)
+	}
+	if got := server.Scrapes(); len(got) != 1 || got[0] != "https://example.com/posts/1" {
+		t.Errorf("Scrapes() = %v", got)
+	}
+
+	// Strict mode answers 404 for URLs without a fixture
+	_, err = client.Scrape(context.Background(), "https://other.example/")
+	var statusErr *scraper.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("unmatched URL: err = %v, want status 404", err)
+	}
+}
+
+func TestScrapeInjectedError(t *testing.T) {
+	server := fakescraper.New(nil, fakescraper.Options{ErrorRate: 1})
+	client := newClient(t, server)
+
+	_, err := client.Scrape(context.Background(), "https://example.com/")
+	var statusErr *scraper.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want status 503", err)
+	}
+}
+
+func TestExitClosesExited(t *testing.T) {
+	server := fakescraper.New(nil, fakescraper.Options{})
+	client := newClient(t, server)
+
+	select {
+	case <-server.Exited():
+		t.Fatal("Exited closed before /exit")
+	default:
+	}
+
+	if err := client.Exit(context.Background()); err != nil {
+		t.Fatalf("Exit: %v", err)
+	}
+	select {
+	case <-server.Exited():
+	case <-time.After(time.Second):
+		t.Fatal("Exited not closed after /exit")
+	}
+}
@@ -0,0 +1,246 @@
+package fieldmap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/textutil"
+)
+
+// Bookmark properties scraped fields can be mapped to
+const (
+	PropertyTitle         = "title"
+	PropertySummary       = "summary"
+	PropertyAuthor        = "author"
+	PropertyDatePublished = "date_published"
+)
+
+// Scraped fields a mapping can read
+const (
+	SourceTitle         = "title"
+	SourceDescription   = "description"
+	SourceAuthor        = "author"
+	SourcePublisher     = "publisher"
+	SourceDatePublished = "date_published"
+	SourceDateModified  = "date_modified"
+	SourceDate          = "date"
+	SourceLang          = "lang"
+	SourceURL           = "url"
+	SourceSubreddit     = "subreddit"
+	SourceContent       = "content"
+)
+
+// maxTextLength is the longest text Notion accepts in a single rich text object
+const maxTextLength = 2000
+
+// knownProperties lists the properties a mapping may write
+var knownProperties = map[string]bool{
+	PropertyTitle: true, PropertySummary: true, PropertyAuthor: true, PropertyDatePublished: true,
+}
+
+// knownSources lists the fields Fields extracts
+var knownSources = map[string]bool{
+	SourceTitle: true, SourceDescription: true, SourceAuthor: true, SourcePublisher: true,
+	SourceDatePublished: true, SourceDateModified: true, SourceDate: true,
+	SourceLang: true, SourceURL: true, SourceSubreddit: true, SourceContent: true,
+}
+
+// Mapping fills a bookmark property from the first scraped field that has a value
+type Mapping struct {
+	Property   string
+	Sources    []string // Tried in order until one yields a value
+	Transforms []Transform
+}
+
+// Transform changes a scraped value before it is written
+type Transform struct {
+	Name string
+	N    int // Length for truncate
+}
+
+// Defaults returns the mappings used for properties the configuration doesn't map
+func Defaults() []Mapping {
+	return []Mapping{
+		{Property: PropertyTitle, Sources: []string{SourceTitle}},
+		{Property: PropertyAuthor, Sources: []string{SourceAuthor}},
+		{Property: PropertySummary, Sources: []string{SourceDescription}},
+		{Property: PropertyDatePublished, Sources: []string{SourceDatePublished, SourceDate}},
+	}
+}
+
+// Parse parses "source|fallback:transform:...=property" mappings separated by commas,
+// e.g. "description|content:first_sentence:truncate(300)=summary"
+// Sources are on the left like the fields of PLATFORM_PROPERTIES
+func Parse(value string) ([]Mapping, error) {
+	var mappings []Mapping
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		rest, property, ok := strings.Cut(entry, "=")
+		property = strings.ToLower(strings.TrimSpace(property))
+		if !ok || property == "" || strings.TrimSpace(rest) == "" {
+			return nil, fmt.Errorf("invalid mapping %q (expected source=property)", entry)
+		}
+		if !knownProperties[property] {
+			return nil, fmt.Errorf("unknown property %q (expected title, summary, author or date_published)", property)
+		}
+
+		parts := strings.Split(rest, ":")
+		mapping := Mapping{Property: property}
+		for _, source := range strings.Split(parts[0], "|") {
+			source = strings.ToLower(strings.TrimSpace(source))
+			if !knownSources[source] {
+				return nil, fmt.Errorf("%s: unknown scraped field %q", property, source)
+			}
+			mapping.Sources = append(mapping.Sources, source)
+		}
+		for _, name := range parts[1:] {
+			transform, err := parseTransform(name)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", property, err)
+			}
+			mapping.Transforms = append(mapping.Transforms, transform)
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+// parseTransform parses a transform name: trim, lower, upper, first_sentence or truncate(N)
+func parseTransform(value string) (Transform, error) {
+	name := strings.ToLower(strings.TrimSpace(value))
+	switch name {
+	case "trim", "lower", "upper", "first_sentence":
+		return Transform{Name: name}, nil
+	}
+	if arg, ok := strings.CutPrefix(name, "truncate("); ok {
+		n, err := strconv.Atoi(strings.TrimSuffix(arg, ")"))
+		if err != nil || !strings.HasSuffix(arg, ")") || n <= 0 {
+			return Transform{}, fmt.Errorf("invalid transform %q (expected truncate(N) with N > 0)", value)
+		}
+		return Transform{Name: "truncate", N: n}, nil
+	}
+	return Transform{}, fmt.Errorf("unknown transform %q (expected trim, lower, upper, first_sentence or truncate(N))", value)
+}
+
+// Merge returns the defaults with every property the overrides map replaced
+func Merge(defaults, overrides []Mapping) []Mapping {
+	merged := make([]Mapping, 0, len(defaults)+len(overrides))
+	seen := make(map[string]bool)
+	for _, mapping := range overrides {
+		if !seen[mapping.Property] {
+			merged = append(merged, mapping)
+			seen[mapping.Property] = true
+		}
+	}
+	for _, mapping := range defaults {
+		if !seen[mapping.Property] {
+			merged = append(merged, mapping)
+		}
+	}
+	return merged
+}
+
+// Fields returns the scraped fields a mapping can read; dates are formatted as YYYY-MM-DD
+func Fields(content *scraper.ScrapedContent) map[string]string {
+	fields := make(map[string]string)
+	if content == nil {
+		return fields
+	}
+	fields[SourceContent] = content.Content
+
+	metadata := content.Metadata
+	if metadata == nil {
+		return fields
+	}
+	fields[SourceTitle] = metadata.Title
+	fields[SourceDescription] = metadata.Description
+	fields[SourceAuthor] = metadata.Author
+	fields[SourcePublisher] = metadata.Publisher
+	fields[SourceURL] = metadata.URL
+	fields[SourceDatePublished] = formatDate(metadata.DatePublished)
+	fields[SourceDateModified] = formatDate(metadata.DateModified)
+	fields[SourceDate] = formatDate(metadata.Date)
+	if metadata.Lang != nil {
+		fields[SourceLang] = *metadata.Lang
+	}
+	if metadata.Subreddit != nil {
+		fields[SourceSubreddit] = *metadata.Subreddit
+	} else if metadata.RedditSubreddit != nil {
+		fields[SourceSubreddit] = *metadata.RedditSubreddit
+	}
+	return fields
+}
+
+// Value returns the first source with a value after the transforms, or ""
+// Values are capped at the Notion rich text limit
+func (m Mapping) Value(fields map[string]string) string {
+	for _, source := range m.Sources {
+		value := strings.TrimSpace(fields[source])
+		for _, transform := range m.Transforms {
+			value = transform.apply(value)
+		}
+		if value != "" {
+			return truncate(value, maxTextLength)
+		}
+	}
+	return ""
+}
+
+// apply runs the transform on a value
+func (t Transform) apply(value string) string {
+	switch t.Name {
+	case "trim":
+		return textutil.CollapseSpace(value)
+	case "lower":
+		return strings.ToLower(value)
+	case "upper":
+		return strings.ToUpper(value)
+	case "first_sentence":
+		return firstSentence(value)
+	case "truncate":
+		return truncate(value, t.N)
+	}
+	return value
+}
+
+// firstSentence returns the text up to the first sentence end, or the first line without one
+func firstSentence(value string) string {
+	value = strings.TrimSpace(value)
+	if line, _, ok := strings.Cut(value, "\n"); ok {
+		value = strings.TrimSpace(line)
+	}
+	for i, r := range value {
+		if r != '.' && r != '!' && r != '?' {
+			continue
+		}
+		next := i + utf8.RuneLen(r)
+		if next == len(value) || value[next] == ' ' {
+			return value[:next]
+		}
+	}
+	return value
+}
+
+// truncate shortens a value to at most n characters, ending it with "…" when cut
+func truncate(value string, n int) string {
+	if utf8.RuneCountInString(value) <= n {
+		return value
+	}
+	runes := []rune(value)
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
+
+// formatDate formats an optional date as YYYY-MM-DD
+func formatDate(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
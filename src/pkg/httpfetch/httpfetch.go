@@ -0,0 +1,223 @@
+package httpfetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/urlpolicy"
+)
+
+const (
+	// UserAgent mimics a desktop browser; many sites and CDNs reject unknown clients
+	UserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0 Safari/537.36"
+
+	// AcceptImage is the Accept header sent when fetching images
+	AcceptImage = "image/avif,image/webp,image/png,image/svg+xml,image/*;q=0.8,*/*;q=0.5"
+
+	// AcceptDecodableImage asks for formats that can be transcoded locally (no AVIF/HEIC)
+	AcceptDecodableImage = "image/webp,image/png,image/jpeg,image/gif,image/svg+xml,image/avif;q=0,image/heic;q=0,image/*;q=0.8,*/*;q=0.5"
+
+	// AcceptHTML is the Accept header sent when fetching pages
+	AcceptHTML = "text/html,application/xhtml+xml;q=0.9,*/*;q=0.5"
+
+	// AcceptManifest is the Accept header sent when fetching web app manifests
+	AcceptManifest = "application/manifest+json,application/json;q=0.9,*/*;q=0.5"
+
+	// AcceptJSON is the Accept header sent when calling JSON APIs
+	AcceptJSON = "application/json"
+
+	defaultTimeout  = 30 * time.Second
+	defaultMaxBytes = 50 * 1024 * 1024
+)
+
+// StatusError is returned by Get for non-200 responses
+type StatusError struct {
+	StatusCode int
+	// RetryAfter is the delay requested by the server's Retry-After header (0 = none)
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("server returned status %d", e.StatusCode)
+}
+
+// Fetcher performs GET requests for resources referenced by scraped pages (images, icons, pages)
+type Fetcher struct {
+	httpClient *http.Client
+	maxBytes   int64
+	policy     *urlpolicy.Policy
+}
+
+// New creates a new fetcher; zero values select a 30s timeout and a 50MB body limit
+func New(timeout time.Duration, maxBytes int64) *Fetcher {
+	return NewWithPolicy(timeout, maxBytes, nil)
+}
+
+// NewWithPolicy creates a fetcher that only requests URLs, redirect targets and
+// addresses the policy allows (nil = no restrictions)
+func NewWithPolicy(timeout time.Duration, maxBytes int64, policy *urlpolicy.Policy) *Fetcher {
+	return NewWithOptions(Options{Timeout: timeout, MaxBytes: maxBytes, Policy: policy})
+}
+
+// Options holds the settings of a Fetcher; the zero value selects the defaults
+type Options struct {
+	// Timeout limits each request (0 = 30s)
+	Timeout time.Duration
+	// MaxBytes limits response bodies (0 = 50MB)
+	MaxBytes int64
+	// Policy restricts which URLs, redirect targets and addresses are requested (nil = no restrictions)
+	Policy *urlpolicy.Policy
+	// Transport sends the requests instead of the policy's transport, e.g. to record or
+	// replay them; URLs and redirects are still checked (nil = the policy's transport)
+	Transport http.RoundTripper
+}
+
+// NewWithOptions creates a fetcher with the given options
+func NewWithOptions(options Options) *Fetcher {
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	maxBytes := options.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes
+	}
+
+	policy := options.Policy
+	httpClient := &http.Client{Timeout: timeout, Transport: options.Transport}
+	if policy != nil {
+		if httpClient.Transport == nil {
+			httpClient.Transport = policy.Transport()
+		}
+		httpClient.CheckRedirect = policy.CheckRedirect
+	}
+
+	return &Fetcher{
+		httpClient: httpClient,
+		maxBytes:   maxBytes,
+		policy:     policy,
+	}
+}
+
+// WithTimeout returns a fetcher sharing this one's settings with a different request timeout
+func (f *Fetcher) WithTimeout(timeout time.Duration) *Fetcher {
+	httpClient := *f.httpClient
+	httpClient.Timeout = timeout
+	return &Fetcher{
+		httpClient: &httpClient,
+		maxBytes:   f.maxBytes,
+		policy:     f.policy,
+	}
+}
+
+// MaxBytes returns the body size limit enforced by ReadBody
+func (f *Fetcher) MaxBytes() int64 {
+	return f.maxBytes
+}
+
+// Get sends a GET request with browser-like headers and the page as Referer
+// The caller must close the response body. Non-200 responses are returned as errors.
+func (f *Fetcher) Get(ctx context.Context, rawURL, referer, accept string) (*http.Response, error) {
+	header := make(http.Header)
+	if accept != "" {
+		header.Set("Accept", accept)
+	}
+	if referer != "" {
+		header.Set("Referer", referer)
+	}
+	return f.GetWithHeader(ctx, rawURL, header)
+}
+
+// GetWithHeader sends a GET request with extra headers (e.g. API credentials)
+// The caller must close the response body. Non-200 responses are returned as errors.
+func (f *Fetcher) GetWithHeader(ctx context.Context, rawURL string, header http.Header) (*http.Response, error) {
+	if f.policy != nil {
+		if err := f.policy.CheckURL(rawURL); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("User-Agent", UserAgent)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: ParseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	if resp.ContentLength > f.maxBytes {
+		resp.Body.Close()
+		return nil, fmt.Errorf("response is %d bytes, limit is %d", resp.ContentLength, f.maxBytes)
+	}
+
+	return resp, nil
+}
+
+// ReadBody reads a response body, failing if it exceeds the size limit
+func (f *Fetcher) ReadBody(resp *http.Response) ([]byte, error) {
+	// Read one byte past the limit to detect oversized bodies without a Content-Length
+	data, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if int64(len(data)) > f.maxBytes {
+		return nil, fmt.Errorf("response exceeds the %d byte limit", f.maxBytes)
+	}
+	return data, nil
+}
+
+// ImageContentType returns the declared media type, sniffing the body when the
+// server sends none or a generic one
+func ImageContentType(header string, data []byte) string {
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err == nil && strings.HasPrefix(mediaType, "image/") {
+		return mediaType
+	}
+
+	sniffed, _, _ := mime.ParseMediaType(http.DetectContentType(data))
+	if sniffed == "text/xml" || sniffed == "text/plain" {
+		// http.DetectContentType does not know SVG
+		if strings.Contains(string(data[:min(len(data), 1024)]), "<svg") {
+			return "image/svg+xml"
+		}
+	}
+	return sniffed
+}
+
+// ParseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func ParseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
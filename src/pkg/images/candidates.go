@@ -0,0 +1,169 @@
+package images
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+)
+
+// Source identifies where an image candidate was found
+type Source string
+
+const (
+	SourceContentImage Source = "content.image"
+	SourceOpenGraph    Source = "og:image"
+	SourceTwitter      Source = "twitter:image"
+	SourceJSONLD       Source = "json-ld"
+	SourceMetadata     Source = "metadata.image"
+	SourceInline       Source = "content"
+)
+
+// sourcePriority ranks sources when probed candidates are otherwise comparable
+var sourcePriority = map[Source]float64{
+	SourceContentImage: 1.0,
+	SourceOpenGraph:    1.0,
+	SourceTwitter:      0.9,
+	SourceJSONLD:       0.8,
+	SourceMetadata:     0.8,
+	SourceInline:       0.5,
+}
+
+// Candidate is a possible cover image for a bookmark
+type Candidate struct {
+	URL    string
+	Source Source
+}
+
+var (
+	markdownImagePattern = regexp.MustCompile(`!\[[^\]]*\]\(\s*<?([^)\s>]+)>?`)
+	htmlImagePattern     = regexp.MustCompile(`(?i)<img[^>]+src\s*=\s*["']([^"']+)["']`)
+)
+
+// CollectCandidates gathers every image URL the scraper returned for a page:
+// the primary image, og:image/twitter:image style fields and JSON-LD images found
+// anywhere in the raw response, and images referenced inline in the content.
+// URLs are resolved against the page URL and de-duplicated in priority order.
+func CollectCandidates(pageURL string, content *scraper.ScrapedContent, rawJSON string) []Candidate {
+	base, _ := url.Parse(pageURL)
+	seen := make(map[string]bool)
+	var candidates []Candidate
+
+	add := func(rawURL string, source Source) {
+		resolved := resolveURL(base, rawURL)
+		if resolved == "" || seen[resolved] {
+			return
+		}
+		seen[resolved] = true
+		candidates = append(candidates, Candidate{URL: resolved, Source: source})
+	}
+
+	if content != nil {
+		if content.Image != nil {
+			add(*content.Image, SourceContentImage)
+		}
+		if content.Metadata != nil && content.Metadata.Image != nil {
+			add(*content.Metadata.Image, SourceMetadata)
+		}
+	}
+
+	// The scraper response can carry more image fields than the typed struct knows about
+	var raw interface{}
+	if rawJSON != "" && json.Unmarshal([]byte(rawJSON), &raw) == nil {
+		walkJSON(raw, "", false, add)
+	}
+
+	if content != nil {
+		for _, pattern := range []*regexp.Regexp{markdownImagePattern, htmlImagePattern} {
+			for _, match := range pattern.FindAllStringSubmatch(content.Content, -1) {
+				add(match[1], SourceInline)
+			}
+		}
+	}
+
+	return candidates
+}
+
+// walkJSON visits every string stored under an image-like key
+// inLD is set once the walk has entered a JSON-LD object (one with "@type")
+func walkJSON(value interface{}, key string, inLD bool, add func(string, Source)) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if _, ok := v["@type"]; ok {
+			inLD = true
+		}
+		// Visit keys in sorted order so candidate order is deterministic
+		keys := make([]string, 0, len(v))
+		for childKey := range v {
+			keys = append(keys, childKey)
+		}
+		sort.Strings(keys)
+
+		for _, childKey := range keys {
+			child := v[childKey]
+			// Nested JSON-LD ImageObject: {"@type": "ImageObject", "url": "..."}
+			if isImageKey(key) && (childKey == "url" || childKey == "contentUrl") {
+				if s, ok := child.(string); ok {
+					add(s, sourceForKey(key, inLD))
+				}
+				continue
+			}
+			walkJSON(child, childKey, inLD, add)
+		}
+	case []interface{}:
+		for _, child := range v {
+			walkJSON(child, key, inLD, add)
+		}
+	case string:
+		if isImageKey(key) {
+			add(v, sourceForKey(key, inLD))
+		}
+	}
+}
+
+// isImageKey reports whether a JSON key holds cover-style images (logos and icons are excluded)
+func isImageKey(key string) bool {
+	k := strings.ToLower(key)
+	if strings.Contains(k, "logo") || strings.Contains(k, "icon") || strings.Contains(k, "avatar") {
+		return false
+	}
+	return strings.Contains(k, "image") || k == "thumbnailurl" || k == "thumbnail"
+}
+
+// sourceForKey maps a JSON key to a candidate source
+func sourceForKey(key string, inLD bool) Source {
+	k := strings.ToLower(strings.NewReplacer(":", "", "_", "", "-", "").Replace(key))
+	switch {
+	case strings.HasPrefix(k, "og"):
+		return SourceOpenGraph
+	case strings.HasPrefix(k, "twitter"):
+		return SourceTwitter
+	case inLD:
+		return SourceJSONLD
+	default:
+		return SourceMetadata
+	}
+}
+
+// resolveURL makes a candidate URL absolute and rejects non-http(s) and data URLs
+func resolveURL(base *url.URL, rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return ""
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		parsed = base.ResolveReference(parsed)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return ""
+	}
+	return parsed.String()
+}
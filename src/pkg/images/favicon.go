@@ -0,0 +1,290 @@
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/httpfetch"
+)
+
+// Icon candidate sources, in preference order when sizes tie
+const (
+	SourceLinkIcon       Source = "link:icon"
+	SourceAppleTouchIcon Source = "apple-touch-icon"
+	SourceManifestIcon   Source = "manifest"
+	SourceFaviconICO     Source = "favicon.ico"
+)
+
+const (
+	// maxHeadBytes limits how much of the page is read looking for <link> tags
+	maxHeadBytes = 1024 * 1024
+	// maxManifestBytes limits the size of a web app manifest
+	maxManifestBytes = 256 * 1024
+	// maxIconCandidates limits how many declared icons are probed
+	maxIconCandidates = 8
+	// vectorIconSize is the size credited to SVG icons, which scale to any size
+	vectorIconSize = 512
+)
+
+// iconSourcePriority breaks ties between icons of the same size
+var iconSourcePriority = map[Source]int{
+	SourceLinkIcon:       3,
+	SourceAppleTouchIcon: 2,
+	SourceManifestIcon:   1,
+	SourceFaviconICO:     0,
+}
+
+// FaviconFinder discovers a site's icon from the page itself when the scraper reports none
+type FaviconFinder struct {
+	fetcher  *httpfetch.Fetcher
+	selector *Selector
+}
+
+// NewFaviconFinder creates a favicon finder
+func NewFaviconFinder(fetcher *httpfetch.Fetcher) *FaviconFinder {
+	return &FaviconFinder{
+		fetcher:  fetcher,
+		selector: NewSelector(fetcher, 1, 1, 0),
+	}
+}
+
+// iconCandidate is an icon declared by the page with its advertised size
+type iconCandidate struct {
+	Candidate
+	declared int
+}
+
+// Find returns the largest usable icon declared by <link rel=icon>, apple-touch-icon or the
+// web manifest, falling back to /favicon.ico on the page's host
+func (f *FaviconFinder) Find(ctx context.Context, pageURL string) (*Probe, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("invalid page URL %q", pageURL)
+	}
+
+	candidates, manifestURL := f.pageIcons(ctx, base)
+	if manifestURL != "" {
+		candidates = append(candidates, f.manifestIcons(ctx, manifestURL, pageURL)...)
+	}
+	candidates = append(candidates, iconCandidate{
+		Candidate: Candidate{URL: base.Scheme + "://" + base.Host + "/favicon.ico", Source: SourceFaviconICO},
+	})
+	candidates = dedupIcons(candidates)
+
+	// Probe the most promising declarations first
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].declared != candidates[j].declared {
+			return candidates[i].declared > candidates[j].declared
+		}
+		return iconSourcePriority[candidates[i].Source] > iconSourcePriority[candidates[j].Source]
+	})
+	if len(candidates) > maxIconCandidates {
+		// Always keep /favicon.ico as the last resort
+		candidates = append(candidates[:maxIconCandidates-1], candidates[len(candidates)-1])
+	}
+
+	plain := make([]Candidate, len(candidates))
+	for i, candidate := range candidates {
+		plain[i] = candidate.Candidate
+	}
+	_, probes := f.selector.Select(ctx, plain, pageURL)
+
+	var best *Probe
+	for i := range probes {
+		if probes[i].Err != nil {
+			continue
+		}
+		if best == nil || iconSize(&probes[i]) > iconSize(best) {
+			best = &probes[i]
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no usable icon found (%d candidates)", len(probes))
+	}
+	return best, nil
+}
+
+// pageIcons reads the page head and returns its declared icons and web manifest URL
+func (f *FaviconFinder) pageIcons(ctx context.Context, base *url.URL) ([]iconCandidate, string) {
+	resp, err := f.fetcher.Get(ctx, base.String(), "", httpfetch.AcceptHTML)
+	if err != nil {
+		return nil, ""
+	}
+	defer resp.Body.Close()
+
+	var candidates []iconCandidate
+	var manifestURL string
+
+	tokenizer := html.NewTokenizer(io.LimitReader(resp.Body, maxHeadBytes))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		if tokenType != html.StartTagToken && tokenType != html.SelfClosingTagToken {
+			continue
+		}
+
+		token := tokenizer.Token()
+		if token.Data == "body" {
+			break
+		}
+		if token.Data == "base" {
+			if href := attr(token, "href"); href != "" {
+				if resolved, err := base.Parse(href); err == nil {
+					base = resolved
+				}
+			}
+			continue
+		}
+		if token.Data != "link" {
+			continue
+		}
+
+		href := attr(token, "href")
+		if href == "" || strings.HasPrefix(href, "data:") {
+			continue
+		}
+		resolved, err := base.Parse(href)
+		if err != nil {
+			continue
+		}
+
+		source, ok := linkSource(attr(token, "rel"))
+		if !ok {
+			continue
+		}
+		if source == SourceManifestIcon {
+			manifestURL = resolved.String()
+			continue
+		}
+		candidates = append(candidates, iconCandidate{
+			Candidate: Candidate{URL: resolved.String(), Source: source},
+			declared:  declaredSize(attr(token, "sizes"), attr(token, "type"), resolved.Path),
+		})
+	}
+
+	return candidates, manifestURL
+}
+
+// manifestIcons returns the icons listed in a web app manifest
+func (f *FaviconFinder) manifestIcons(ctx context.Context, manifestURL, referer string) []iconCandidate {
+	resp, err := f.fetcher.Get(ctx, manifestURL, referer, httpfetch.AcceptManifest)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	var manifest struct {
+		Icons []struct {
+			Src     string `json:"src"`
+			Sizes   string `json:"sizes"`
+			Type    string `json:"type"`
+			Purpose string `json:"purpose"`
+		} `json:"icons"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestBytes)).Decode(&manifest); err != nil {
+		return nil
+	}
+
+	base, err := url.Parse(manifestURL)
+	if err != nil {
+		return nil
+	}
+
+	var candidates []iconCandidate
+	for _, icon := range manifest.Icons {
+		// Maskable and monochrome icons are cropped or flattened by the OS; skip them
+		if icon.Purpose != "" && !strings.Contains(icon.Purpose, "any") {
+			continue
+		}
+		resolved, err := base.Parse(icon.Src)
+		if err != nil || icon.Src == "" {
+			continue
+		}
+		candidates = append(candidates, iconCandidate{
+			Candidate: Candidate{URL: resolved.String(), Source: SourceManifestIcon},
+			declared:  declaredSize(icon.Sizes, icon.Type, resolved.Path),
+		})
+	}
+	return candidates
+}
+
+// linkSource maps a <link rel> value to an icon source; ok is false for unrelated links
+func linkSource(rel string) (Source, bool) {
+	for _, value := range strings.Fields(strings.ToLower(rel)) {
+		switch value {
+		case "icon":
+			return SourceLinkIcon, true
+		case "apple-touch-icon", "apple-touch-icon-precomposed":
+			return SourceAppleTouchIcon, true
+		case "manifest":
+			return SourceManifestIcon, true
+		}
+	}
+	return "", false
+}
+
+// declaredSize returns the largest size advertised by a sizes attribute ("16x16 32x32", "any")
+func declaredSize(sizes, mediaType, path string) int {
+	if isVector(mediaType, path) || strings.EqualFold(strings.TrimSpace(sizes), "any") {
+		return vectorIconSize
+	}
+
+	largest := 0
+	for _, size := range strings.Fields(strings.ToLower(sizes)) {
+		width, _, ok := strings.Cut(size, "x")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(width); err == nil && n > largest {
+			largest = n
+		}
+	}
+	return largest
+}
+
+// iconSize ranks probed icons by their smaller side, crediting vector icons with a large size
+func iconSize(probe *Probe) int {
+	if probe.ContentType == "image/svg+xml" {
+		return vectorIconSize
+	}
+	return min(probe.Width, probe.Height)
+}
+
+// isVector reports whether an icon is an SVG
+func isVector(mediaType, path string) bool {
+	return strings.Contains(mediaType, "svg") || strings.HasSuffix(strings.ToLower(path), ".svg")
+}
+
+// dedupIcons drops repeated URLs, keeping the first declaration
+func dedupIcons(candidates []iconCandidate) []iconCandidate {
+	seen := make(map[string]bool, len(candidates))
+	result := candidates[:0]
+	for _, candidate := range candidates {
+		if seen[candidate.URL] {
+			continue
+		}
+		seen[candidate.URL] = true
+		result = append(result, candidate)
+	}
+	return result
+}
+
+// attr returns the value of an attribute of an HTML token
+func attr(token html.Token, name string) string {
+	for _, a := range token.Attr {
+		if a.Key == name {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,181 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/bmp"
+)
+
+// icoDirEntrySize is the size of one ICONDIRENTRY record
+const icoDirEntrySize = 16
+
+// pngSignature starts every PNG file; ICO entries may embed PNGs directly
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+func init() {
+	// Lets image.Decode and image.DecodeConfig (used when probing favicons) read .ico files
+	image.RegisterFormat("ico", "\x00\x00\x01\x00", func(r io.Reader) (image.Image, error) {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return decodeICO(data)
+	}, decodeICOConfig)
+}
+
+// decodeICOConfig returns the dimensions of the largest entry in the ICO directory
+func decodeICOConfig(r io.Reader) (image.Config, error) {
+	header := make([]byte, 6)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return image.Config{}, err
+	}
+	count := int(binary.LittleEndian.Uint16(header[4:6]))
+	if count == 0 {
+		return image.Config{}, fmt.Errorf("empty ICO directory")
+	}
+
+	directory := make([]byte, count*icoDirEntrySize)
+	if _, err := io.ReadFull(r, directory); err != nil {
+		return image.Config{}, fmt.Errorf("truncated ICO directory")
+	}
+
+	config := image.Config{ColorModel: color.NRGBAModel}
+	for i := 0; i < count; i++ {
+		width, height := icoEntrySize(directory[i*icoDirEntrySize:])
+		if width*height > config.Width*config.Height {
+			config.Width, config.Height = width, height
+		}
+	}
+	return config, nil
+}
+
+// icoEntrySize reads the dimensions of a directory entry (0 means 256px)
+func icoEntrySize(entry []byte) (int, int) {
+	width, height := int(entry[0]), int(entry[1])
+	if width == 0 {
+		width = 256
+	}
+	if height == 0 {
+		height = 256
+	}
+	return width, height
+}
+
+// decodeICO decodes the largest image stored in a Windows .ico file
+// Entries are either embedded PNGs or BMP DIBs without a file header
+func decodeICO(data []byte) (image.Image, error) {
+	if len(data) < 6 || binary.LittleEndian.Uint16(data[0:2]) != 0 || binary.LittleEndian.Uint16(data[2:4]) != 1 {
+		return nil, fmt.Errorf("not an ICO file")
+	}
+
+	count := int(binary.LittleEndian.Uint16(data[4:6]))
+	if count == 0 || len(data) < 6+count*icoDirEntrySize {
+		return nil, fmt.Errorf("truncated ICO directory")
+	}
+
+	// Pick the entry with the largest area (0 in the directory means 256px)
+	bestIndex, bestArea := -1, 0
+	for i := 0; i < count; i++ {
+		width, height := icoEntrySize(data[6+i*icoDirEntrySize:])
+		if width*height > bestArea {
+			bestIndex, bestArea = i, width*height
+		}
+	}
+
+	entry := data[6+bestIndex*icoDirEntrySize:]
+	size := int(binary.LittleEndian.Uint32(entry[8:12]))
+	offset := int(binary.LittleEndian.Uint32(entry[12:16]))
+	if offset < 0 || size <= 0 || offset+size > len(data) {
+		return nil, fmt.Errorf("ICO entry out of bounds")
+	}
+	payload := data[offset : offset+size]
+
+	if bytes.HasPrefix(payload, pngSignature) {
+		config, err := png.DecodeConfig(bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPixels(config.Width, config.Height); err != nil {
+			return nil, err
+		}
+		return png.Decode(bytes.NewReader(payload))
+	}
+	return decodeICOBitmap(payload)
+}
+
+// decodeICOBitmap decodes a BMP DIB stored in an ICO entry.
+// The DIB header reports twice the real height (XOR image followed by the AND mask),
+// so the height is halved and a BMP file header is prepended for the bmp decoder.
+func decodeICOBitmap(dib []byte) (image.Image, error) {
+	if len(dib) < 40 {
+		return nil, fmt.Errorf("truncated ICO bitmap")
+	}
+	width := int(int32(binary.LittleEndian.Uint32(dib[4:8])))
+	height := int(int32(binary.LittleEndian.Uint32(dib[8:12]))) / 2
+	if height < 0 {
+		// Negative heights mark top-down bitmaps
+		height = -height
+	}
+	if err := checkPixels(width, height); err != nil {
+		return nil, err
+	}
+
+	if binary.LittleEndian.Uint16(dib[14:16]) == 32 {
+		return decodeICOBitmap32(dib)
+	}
+
+	header := make([]byte, len(dib))
+	copy(header, dib)
+	rawHeight := int32(binary.LittleEndian.Uint32(header[8:12]))
+	binary.LittleEndian.PutUint32(header[8:12], uint32(rawHeight/2))
+	// The AND mask follows the pixels; drop the declared image size so the decoder ignores it
+	binary.LittleEndian.PutUint32(header[20:24], 0)
+
+	headerSize := binary.LittleEndian.Uint32(header[0:4])
+	bitCount := binary.LittleEndian.Uint16(header[14:16])
+	paletteSize := uint32(0)
+	if bitCount <= 8 {
+		colors := binary.LittleEndian.Uint32(header[32:36])
+		if colors == 0 {
+			colors = 1 << bitCount
+		}
+		paletteSize = colors * 4
+	}
+
+	fileHeader := make([]byte, 14)
+	fileHeader[0], fileHeader[1] = 'B', 'M'
+	binary.LittleEndian.PutUint32(fileHeader[2:6], uint32(14+len(header)))
+	binary.LittleEndian.PutUint32(fileHeader[10:14], 14+headerSize+paletteSize)
+
+	return bmp.Decode(bytes.NewReader(append(fileHeader, header...)))
+}
+
+// decodeICOBitmap32 decodes a 32-bit BGRA ICO bitmap keeping its alpha channel
+// (the bmp package treats 32-bit BITMAPINFOHEADER pixels as opaque)
+func decodeICOBitmap32(dib []byte) (image.Image, error) {
+	headerSize := int(binary.LittleEndian.Uint32(dib[0:4]))
+	width := int(int32(binary.LittleEndian.Uint32(dib[4:8])))
+	height := int(int32(binary.LittleEndian.Uint32(dib[8:12]))) / 2
+	if width <= 0 || height <= 0 || len(dib) < headerSize+width*height*4 {
+		return nil, fmt.Errorf("truncated ICO bitmap")
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	pixels := dib[headerSize:]
+	for y := 0; y < height; y++ {
+		// Rows are stored bottom-up
+		row := pixels[(height-1-y)*width*4:]
+		for x := 0; x < width; x++ {
+			b, g, r, a := row[x*4], row[x*4+1], row[x*4+2], row[x*4+3]
+			i := img.PixOffset(x, y)
+			img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = r, g, b, a
+		}
+	}
+	return img, nil
+}
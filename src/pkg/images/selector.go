@@ -0,0 +1,198 @@
+package images
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	// Register decoders so image.DecodeConfig can read dimensions
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/httpfetch"
+)
+
+const (
+	// probePeekBytes is how much of the body is buffered to sniff the type and read the header
+	probePeekBytes = 4096
+	// idealAspect is the Open Graph recommended aspect ratio (1200x630)
+	idealAspect = 1.91
+	// maxScoredArea caps the area bonus so huge images don't beat a well-shaped one
+	maxScoredArea = 2400 * 1260
+)
+
+var (
+	svgWidthPattern   = regexp.MustCompile(`(?i)<svg[^>]*\swidth\s*=\s*["']\s*([0-9.]+)(?:px)?\s*["']`)
+	svgHeightPattern  = regexp.MustCompile(`(?i)<svg[^>]*\sheight\s*=\s*["']\s*([0-9.]+)(?:px)?\s*["']`)
+	svgViewBoxPattern = regexp.MustCompile(`(?i)<svg[^>]*\sviewBox\s*=\s*["']\s*[-0-9.]+[\s,]+[-0-9.]+[\s,]+([0-9.]+)[\s,]+([0-9.]+)\s*["']`)
+)
+
+// Probe is the result of inspecting a candidate image
+type Probe struct {
+	Candidate
+	ContentType string
+	Width       int
+	Height      int
+	// Err is set when the candidate was rejected
+	Err error
+}
+
+// Selector probes image candidates and picks the best one for a cover
+type Selector struct {
+	fetcher       *httpfetch.Fetcher
+	minWidth      int
+	minHeight     int
+	maxCandidates int
+}
+
+// NewSelector creates a selector that rejects images smaller than minWidth x minHeight
+// and probes at most maxCandidates candidates (0 = no limit)
+func NewSelector(fetcher *httpfetch.Fetcher, minWidth, minHeight, maxCandidates int) *Selector {
+	return &Selector{
+		fetcher:       fetcher,
+		minWidth:      minWidth,
+		minHeight:     minHeight,
+		maxCandidates: maxCandidates,
+	}
+}
+
+// Select probes the candidates concurrently and returns the best usable one
+// (nil if every candidate was rejected) together with all probe results in candidate order
+func (s *Selector) Select(ctx context.Context, candidates []Candidate, referer string) (*Probe, []Probe) {
+	if s.maxCandidates > 0 && len(candidates) > s.maxCandidates {
+		candidates = candidates[:s.maxCandidates]
+	}
+
+	probes := make([]Probe, len(candidates))
+	var wg sync.WaitGroup
+	for i, candidate := range candidates {
+		wg.Add(1)
+		go func(i int, candidate Candidate) {
+			defer wg.Done()
+			probes[i] = s.Probe(ctx, candidate, referer)
+		}(i, candidate)
+	}
+	wg.Wait()
+
+	var best *Probe
+	bestScore := 0.0
+	for i := range probes {
+		if probes[i].Err != nil {
+			continue
+		}
+		if score := probes[i].score(); best == nil || score > bestScore {
+			best = &probes[i]
+			bestScore = score
+		}
+	}
+
+	return best, probes
+}
+
+// Probe fetches the start of a candidate image and checks its content type and dimensions
+func (s *Selector) Probe(ctx context.Context, candidate Candidate, referer string) Probe {
+	probe := Probe{Candidate: candidate}
+
+	resp, err := s.fetcher.Get(ctx, candidate.URL, referer, httpfetch.AcceptImage)
+	if err != nil {
+		probe.Err = err
+		return probe
+	}
+	defer resp.Body.Close()
+
+	reader := bufio.NewReaderSize(io.LimitReader(resp.Body, s.fetcher.MaxBytes()), probePeekBytes)
+	head, _ := reader.Peek(probePeekBytes)
+	if len(head) == 0 {
+		probe.Err = fmt.Errorf("empty response")
+		return probe
+	}
+
+	probe.ContentType = httpfetch.ImageContentType(resp.Header.Get("Content-Type"), head)
+	if !strings.HasPrefix(probe.ContentType, "image/") {
+		probe.Err = fmt.Errorf("not an image (%s)", probe.ContentType)
+		return probe
+	}
+
+	if probe.ContentType == "image/svg+xml" {
+		probe.Width, probe.Height = svgDimensions(string(head))
+	} else if config, _, err := image.DecodeConfig(reader); err == nil {
+		probe.Width, probe.Height = config.Width, config.Height
+	}
+
+	switch {
+	case probe.Width == 0 || probe.Height == 0:
+		probe.Err = fmt.Errorf("unknown dimensions (%s)", probe.ContentType)
+	case probe.Width < s.minWidth || probe.Height < s.minHeight:
+		probe.Err = fmt.Errorf("too small (%dx%d, minimum %dx%d)", probe.Width, probe.Height, s.minWidth, s.minHeight)
+	}
+
+	return probe
+}
+
+// score ranks usable images: larger is better, shapes close to a cover are better,
+// and primary metadata images beat images found inline
+func (p *Probe) score() float64 {
+	area := float64(p.Width * p.Height)
+	if area > maxScoredArea {
+		area = maxScoredArea
+	}
+
+	aspect := float64(p.Width) / float64(p.Height)
+	aspectFactor := 1.0
+	switch {
+	case aspect > 4 || aspect < 0.4:
+		// Banners, spacers and tall strips make poor covers
+		aspectFactor = 0.2
+	case aspect < 1:
+		aspectFactor = 0.6
+	default:
+		if diff := aspect - idealAspect; diff > 1 || diff < -1 {
+			aspectFactor = 0.8
+		}
+	}
+
+	priority, ok := sourcePriority[p.Source]
+	if !ok {
+		priority = 0.5
+	}
+
+	return area * aspectFactor * priority
+}
+
+// svgDimensions reads the intrinsic size of an SVG from its width/height or viewBox attributes
+func svgDimensions(head string) (int, int) {
+	width := parseSVGNumber(svgWidthPattern.FindStringSubmatch(head))
+	height := parseSVGNumber(svgHeightPattern.FindStringSubmatch(head))
+	if width > 0 && height > 0 {
+		return width, height
+	}
+
+	if match := svgViewBoxPattern.FindStringSubmatch(head); match != nil {
+		w, _ := strconv.ParseFloat(match[1], 64)
+		h, _ := strconv.ParseFloat(match[2], 64)
+		return int(w), int(h)
+	}
+	return 0, 0
+}
+
+// parseSVGNumber parses the first submatch of an SVG attribute match
+func parseSVGNumber(match []string) int {
+	if len(match) < 2 {
+		return 0
+	}
+	value, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0
+	}
+	return int(value)
+}
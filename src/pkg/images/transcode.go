@@ -0,0 +1,351 @@
+package images
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/draw"
+
+	// Register additional decoders for image.Decode
+	_ "golang.org/x/image/tiff"
+)
+
+// Format is the output format of transcoded cover images
+type Format string
+
+const (
+	// FormatAuto keeps formats Notion renders well and picks PNG (transparent) or JPEG (opaque) otherwise
+	FormatAuto Format = "auto"
+	FormatPNG  Format = "png"
+	FormatJPEG Format = "jpeg"
+	FormatWebP Format = "webp"
+)
+
+const (
+	defaultIconSize = 256
+	// defaultSVGSize is the raster size for SVGs without usable dimensions
+	defaultSVGSize = 1024
+	// maxShrinkSteps bounds how often an image is downscaled to meet the byte limit
+	maxShrinkSteps = 6
+	// maxDecodePixels rejects images whose declared size would need gigabytes to decode
+	maxDecodePixels = 40_000_000
+)
+
+// jpegQualitySteps are tried in order when a JPEG is over the byte limit
+var jpegQualitySteps = []int{85, 75, 65, 50}
+
+// ParseFormat parses an output format name
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(strings.TrimSpace(value))); format {
+	case FormatAuto, FormatPNG, FormatJPEG, FormatWebP:
+		return format, nil
+	case "jpg":
+		return FormatJPEG, nil
+	default:
+		return "", fmt.Errorf("unknown image format %q (expected auto, png, jpeg or webp)", value)
+	}
+}
+
+// TranscodeOptions configures a Transcoder
+type TranscodeOptions struct {
+	Format Format
+	// MaxDimension limits the longest side of cover images (0 = no limit)
+	MaxDimension int
+	// MaxBytes limits the encoded size (0 = no limit)
+	MaxBytes int
+	// IconSize is the side of square icons (0 = 256)
+	IconSize int
+}
+
+// Transcoder converts scraped images into formats and sizes Notion handles well
+type Transcoder struct {
+	format       Format
+	maxDimension int
+	maxBytes     int
+	iconSize     int
+}
+
+// NewTranscoder creates a new transcoder
+func NewTranscoder(options TranscodeOptions) *Transcoder {
+	format := options.Format
+	if format == "" {
+		format = FormatAuto
+	}
+	iconSize := options.IconSize
+	if iconSize <= 0 {
+		iconSize = defaultIconSize
+	}
+
+	return &Transcoder{
+		format:       format,
+		maxDimension: options.MaxDimension,
+		maxBytes:     options.MaxBytes,
+		iconSize:     iconSize,
+	}
+}
+
+// Transform converts a downloaded image for upload; icon selects square icon output
+// The signature matches notion.ImageTransformer
+func (t *Transcoder) Transform(data []byte, contentType string, icon bool) ([]byte, string, error) {
+	if icon {
+		return t.TranscodeIcon(data, contentType)
+	}
+	return t.Transcode(data, contentType)
+}
+
+// Transcode converts a cover image to PNG, JPEG or WebP, downscaling it to the configured
+// maximum dimension and byte size. Images that already fit are returned unchanged.
+func (t *Transcoder) Transcode(data []byte, contentType string) ([]byte, string, error) {
+	if t.fits(data, contentType) {
+		return data, contentType, nil
+	}
+
+	img, err := decode(data, contentType, t.maxDimension)
+	if err != nil {
+		return nil, "", err
+	}
+
+	img = fitWithin(img, t.maxDimension)
+	format := t.outputFormat(img)
+
+	for step := 0; ; step++ {
+		encoded, outType, err := t.encodeWithinLimit(img, format)
+		if err != nil {
+			return nil, "", err
+		}
+		if t.maxBytes <= 0 || len(encoded) <= t.maxBytes {
+			return encoded, outType, nil
+		}
+		if step >= maxShrinkSteps {
+			return nil, "", fmt.Errorf("image is still %d bytes after downscaling, limit is %d", len(encoded), t.maxBytes)
+		}
+
+		bounds := img.Bounds()
+		img = scale(img, bounds.Dx()*3/4, bounds.Dy()*3/4)
+	}
+}
+
+// TranscodeIcon converts a favicon (ICO, SVG, PNG, ...) into a square transparent PNG
+func (t *Transcoder) TranscodeIcon(data []byte, contentType string) ([]byte, string, error) {
+	img, err := decode(data, contentType, t.iconSize)
+	if err != nil {
+		return nil, "", err
+	}
+
+	img = fitWithin(img, t.iconSize)
+	bounds := img.Bounds()
+	side := bounds.Dx()
+	if bounds.Dy() > side {
+		side = bounds.Dy()
+	}
+
+	// Center the icon on a transparent square canvas
+	square := image.NewNRGBA(image.Rect(0, 0, side, side))
+	offset := image.Pt((side-bounds.Dx())/2, (side-bounds.Dy())/2)
+	draw.Draw(square, bounds.Sub(bounds.Min).Add(offset), img, bounds.Min, draw.Src)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, square); err != nil {
+		return nil, "", fmt.Errorf("failed to encode icon: %w", err)
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+// fits reports whether an image can be uploaded as-is
+func (t *Transcoder) fits(data []byte, contentType string) bool {
+	if t.maxBytes > 0 && len(data) > t.maxBytes {
+		return false
+	}
+
+	switch t.format {
+	case FormatPNG, FormatJPEG, FormatWebP:
+		if contentType != "image/"+string(t.format) {
+			return false
+		}
+	default:
+		switch contentType {
+		case "image/png", "image/jpeg", "image/gif", "image/webp":
+		default:
+			return false
+		}
+	}
+
+	if t.maxDimension <= 0 {
+		return true
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+	return config.Width <= t.maxDimension && config.Height <= t.maxDimension
+}
+
+// outputFormat resolves FormatAuto for a decoded image
+func (t *Transcoder) outputFormat(img image.Image) Format {
+	if t.format != FormatAuto {
+		return t.format
+	}
+	if isOpaque(img) {
+		return FormatJPEG
+	}
+	return FormatPNG
+}
+
+// encodeWithinLimit encodes an image, lowering JPEG quality before the caller resorts to downscaling
+func (t *Transcoder) encodeWithinLimit(img image.Image, format Format) ([]byte, string, error) {
+	var buf bytes.Buffer
+
+	switch format {
+	case FormatJPEG:
+		for _, quality := range jpegQualitySteps {
+			buf.Reset()
+			if err := jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: quality}); err != nil {
+				return nil, "", fmt.Errorf("failed to encode JPEG: %w", err)
+			}
+			if t.maxBytes <= 0 || buf.Len() <= t.maxBytes {
+				break
+			}
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	case FormatWebP:
+		if err := nativewebp.Encode(&buf, img, nil); err != nil {
+			return nil, "", fmt.Errorf("failed to encode WebP: %w", err)
+		}
+		return buf.Bytes(), "image/webp", nil
+	default:
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("failed to encode PNG: %w", err)
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+}
+
+// decode decodes any supported input format; SVGs are rasterized with their longest side at rasterSize
+func decode(data []byte, contentType string, rasterSize int) (image.Image, error) {
+	switch contentType {
+	case "image/svg+xml":
+		return rasterizeSVG(data, rasterSize)
+	case "image/x-icon", "image/vnd.microsoft.icon", "image/ico":
+		return decodeICO(data)
+	case "image/avif", "image/heic", "image/heif":
+		return nil, fmt.Errorf("cannot transcode %s locally", contentType)
+	}
+
+	// The header is checked first, so a tiny file declaring a huge canvas is never decoded
+	if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		if err := checkPixels(config.Width, config.Height); err != nil {
+			return nil, err
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		// Some servers label favicons as image/png or octet-stream
+		if ico, icoErr := decodeICO(data); icoErr == nil {
+			return ico, nil
+		}
+		return nil, fmt.Errorf("failed to decode %s: %w", contentType, err)
+	}
+	return img, nil
+}
+
+// checkPixels rejects image dimensions above the decode budget
+func checkPixels(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid image size %dx%d", width, height)
+	}
+	if int64(width)*int64(height) > maxDecodePixels {
+		return fmt.Errorf("image is %dx%d pixels, limit is %d", width, height, maxDecodePixels)
+	}
+	return nil
+}
+
+// rasterizeSVG renders an SVG so its longest side is size pixels
+func rasterizeSVG(data []byte, size int) (image.Image, error) {
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(data), oksvg.IgnoreErrorMode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SVG: %w", err)
+	}
+
+	if size <= 0 {
+		size = defaultSVGSize
+	}
+
+	width, height := icon.ViewBox.W, icon.ViewBox.H
+	if width <= 0 || height <= 0 {
+		width, height = 1, 1
+	}
+	w, h := size, size
+	if width > height {
+		h = int(float64(size) * height / width)
+	} else {
+		w = int(float64(size) * width / height)
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	icon.SetTarget(0, 0, float64(w), float64(h))
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	scanner := rasterx.NewScannerGV(w, h, img, img.Bounds())
+	icon.Draw(rasterx.NewDasher(w, h, scanner), 1)
+	return img, nil
+}
+
+// fitWithin downscales an image so its longest side is at most maxDimension
+func fitWithin(img image.Image, maxDimension int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if maxDimension <= 0 || (width <= maxDimension && height <= maxDimension) {
+		return img
+	}
+
+	if width >= height {
+		return scale(img, maxDimension, height*maxDimension/width)
+	}
+	return scale(img, width*maxDimension/height, maxDimension)
+}
+
+// scale resizes an image with a high-quality filter
+func scale(img image.Image, width, height int) image.Image {
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// isOpaque reports whether every pixel is fully opaque
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// flatten composites an image onto white so transparent areas don't turn black in JPEG
+func flatten(img image.Image) image.Image {
+	if isOpaque(img) {
+		return img
+	}
+	bounds := img.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,423 @@
+package notion
+
+import (
+	"encoding/json"
+
+	"github.com/jomei/notionapi"
+)
+
+// Typed builders for raw Notion request bodies.
+// The notionapi library (v1.13.3) does not know about file_upload sources, so
+// content that is sent through raw HTTP calls is built with these types instead
+// of hand-written map[string]interface{} values.
+
+// maxRichTextLength is the maximum number of characters Notion accepts in a single text object
+const maxRichTextLength = 2000
+
+// BlockType represents the type of a Notion block
+type BlockType string
+
+const (
+	BlockTypeParagraph        BlockType = "paragraph"
+	BlockTypeHeading1         BlockType = "heading_1"
+	BlockTypeHeading2         BlockType = "heading_2"
+	BlockTypeHeading3         BlockType = "heading_3"
+	BlockTypeCallout          BlockType = "callout"
+	BlockTypeToggle           BlockType = "toggle"
+	BlockTypeCode             BlockType = "code"
+	BlockTypeQuote            BlockType = "quote"
+	BlockTypeBulletedListItem BlockType = "bulleted_list_item"
+	BlockTypeNumberedListItem BlockType = "numbered_list_item"
+	BlockTypeDivider          BlockType = "divider"
+	BlockTypeImage            BlockType = "image"
+	BlockTypeFile             BlockType = "file"
+	BlockTypeVideo            BlockType = "video"
+	BlockTypeBookmark         BlockType = "bookmark"
+	BlockTypeEmbed            BlockType = "embed"
+	BlockTypeTable            BlockType = "table"
+	BlockTypeTableRow         BlockType = "table_row"
+)
+
+// FileSourceType represents where a file referenced by a block, cover or icon lives
+type FileSourceType string
+
+const (
+	// FileSourceTypeFileUpload references a file uploaded through the File Upload API
+	FileSourceTypeFileUpload FileSourceType = "file_upload"
+	// FileSourceTypeExternal references a file hosted at an external URL
+	FileSourceTypeExternal FileSourceType = "external"
+)
+
+// Annotations holds the styling applied to a rich text object
+type Annotations struct {
+	Bold          bool            `json:"bold"`
+	Italic        bool            `json:"italic"`
+	Strikethrough bool            `json:"strikethrough"`
+	Underline     bool            `json:"underline"`
+	Code          bool            `json:"code"`
+	Color         notionapi.Color `json:"color,omitempty"`
+}
+
+// Link is the target of a rich text link
+type Link struct {
+	URL string `json:"url"`
+}
+
+// TextContent is the content of a text rich text object
+type TextContent struct {
+	Content string `json:"content"`
+	Link    *Link  `json:"link,omitempty"`
+}
+
+// RichText is a single rich text object in a request body
+type RichText struct {
+	Type        string       `json:"type"`
+	Text        *TextContent `json:"text"`
+	Annotations *Annotations `json:"annotations,omitempty"`
+}
+
+// Text creates a plain rich text object
+func Text(content string) RichText {
+	return RichText{
+		Type: "text",
+		Text: &TextContent{Content: content},
+	}
+}
+
+// LinkText creates a rich text object that links to the given URL
+func LinkText(content, url string) RichText {
+	return Text(content).WithLink(url)
+}
+
+// SplitText converts a string of any length into rich text objects that fit Notion's per-object limit
+func SplitText(content string) []RichText {
+	runes := []rune(content)
+	if len(runes) == 0 {
+		return []RichText{}
+	}
+
+	result := make([]RichText, 0, len(runes)/maxRichTextLength+1)
+	for start := 0; start < len(runes); start += maxRichTextLength {
+		end := start + maxRichTextLength
+		if end > len(runes) {
+			end = len(runes)
+		}
+		result = append(result, Text(string(runes[start:end])))
+	}
+	return result
+}
+
+// annotations returns a copy of the annotations so builder methods never share state
+func (r RichText) annotations() *Annotations {
+	if r.Annotations == nil {
+		return &Annotations{}
+	}
+	copied := *r.Annotations
+	return &copied
+}
+
+// Bold returns a copy of the rich text with bold styling
+func (r RichText) Bold() RichText {
+	r.Annotations = r.annotations()
+	r.Annotations.Bold = true
+	return r
+}
+
+// Italic returns a copy of the rich text with italic styling
+func (r RichText) Italic() RichText {
+	r.Annotations = r.annotations()
+	r.Annotations.Italic = true
+	return r
+}
+
+// Strikethrough returns a copy of the rich text with strikethrough styling
+func (r RichText) Strikethrough() RichText {
+	r.Annotations = r.annotations()
+	r.Annotations.Strikethrough = true
+	return r
+}
+
+// Underline returns a copy of the rich text with underline styling
+func (r RichText) Underline() RichText {
+	r.Annotations = r.annotations()
+	r.Annotations.Underline = true
+	return r
+}
+
+// Code returns a copy of the rich text with inline code styling
+func (r RichText) Code() RichText {
+	r.Annotations = r.annotations()
+	r.Annotations.Code = true
+	return r
+}
+
+// WithColor returns a copy of the rich text with the given color
+func (r RichText) WithColor(color notionapi.Color) RichText {
+	r.Annotations = r.annotations()
+	r.Annotations.Color = color
+	return r
+}
+
+// WithLink returns a copy of the rich text linking to the given URL
+func (r RichText) WithLink(url string) RichText {
+	text := *r.Text
+	text.Link = &Link{URL: url}
+	r.Text = &text
+	return r
+}
+
+// FileUploadRef references a file uploaded through the File Upload API
+type FileUploadRef struct {
+	ID string `json:"id"`
+}
+
+// ExternalFile references a file hosted at an external URL
+type ExternalFile struct {
+	URL string `json:"url"`
+}
+
+// FileSource is a file object used by covers, media blocks and files properties
+type FileSource struct {
+	Type       FileSourceType `json:"type"`
+	FileUpload *FileUploadRef `json:"file_upload,omitempty"`
+	External   *ExternalFile  `json:"external,omitempty"`
+}
+
+// FileUploadSource creates a file source that references a File Upload ID
+func FileUploadSource(fileUploadID string) FileSource {
+	return FileSource{
+		Type:       FileSourceTypeFileUpload,
+		FileUpload: &FileUploadRef{ID: fileUploadID},
+	}
+}
+
+// ExternalSource creates a file source that references an external URL
+func ExternalSource(url string) FileSource {
+	return FileSource{
+		Type:     FileSourceTypeExternal,
+		External: &ExternalFile{URL: url},
+	}
+}
+
+// Icon is a page or callout icon
+type Icon struct {
+	Type       string         `json:"type"`
+	Emoji      string         `json:"emoji,omitempty"`
+	FileUpload *FileUploadRef `json:"file_upload,omitempty"`
+	External   *ExternalFile  `json:"external,omitempty"`
+}
+
+// EmojiIcon creates an emoji icon
+func EmojiIcon(emoji string) *Icon {
+	return &Icon{Type: "emoji", Emoji: emoji}
+}
+
+// FileIcon creates an icon from a file source
+func FileIcon(source FileSource) *Icon {
+	return &Icon{
+		Type:       string(source.Type),
+		FileUpload: source.FileUpload,
+		External:   source.External,
+	}
+}
+
+// PageUpdate is the request body for updating a page's cover, icon or properties
+type PageUpdate struct {
+	Cover      *FileSource            `json:"cover,omitempty"`
+	Icon       *Icon                  `json:"icon,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// AppendChildrenRequest is the request body for appending blocks to a page or block
+type AppendChildrenRequest struct {
+	Children []Block `json:"children"`
+}
+
+// Block is a single block in a request body
+type Block struct {
+	Type    BlockType
+	Payload interface{}
+}
+
+// MarshalJSON encodes the block in Notion's {"type": T, T: payload} layout
+func (b Block) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"object":       "block",
+		"type":         b.Type,
+		string(b.Type): b.Payload,
+	})
+}
+
+// TextBlock is the payload of paragraph, quote, toggle and list item blocks
+type TextBlock struct {
+	RichText []RichText      `json:"rich_text"`
+	Color    notionapi.Color `json:"color,omitempty"`
+	Children []Block         `json:"children,omitempty"`
+}
+
+// HeadingBlock is the payload of heading blocks
+type HeadingBlock struct {
+	RichText     []RichText      `json:"rich_text"`
+	Color        notionapi.Color `json:"color,omitempty"`
+	IsToggleable bool            `json:"is_toggleable,omitempty"`
+}
+
+// CalloutBlock is the payload of callout blocks
+type CalloutBlock struct {
+	RichText []RichText      `json:"rich_text"`
+	Icon     *Icon           `json:"icon,omitempty"`
+	Color    notionapi.Color `json:"color,omitempty"`
+	Children []Block         `json:"children,omitempty"`
+}
+
+// CodeBlock is the payload of code blocks
+type CodeBlock struct {
+	RichText []RichText `json:"rich_text"`
+	Caption  []RichText `json:"caption,omitempty"`
+	Language string     `json:"language"`
+}
+
+// MediaBlock is the payload of image and other file-backed blocks
+type MediaBlock struct {
+	FileSource
+	Caption []RichText `json:"caption,omitempty"`
+	Name    string     `json:"name,omitempty"`
+}
+
+// LinkBlock is the payload of bookmark and embed blocks
+type LinkBlock struct {
+	URL     string     `json:"url"`
+	Caption []RichText `json:"caption,omitempty"`
+}
+
+// TableBlock is the payload of table blocks
+type TableBlock struct {
+	TableWidth      int     `json:"table_width"`
+	HasColumnHeader bool    `json:"has_column_header"`
+	HasRowHeader    bool    `json:"has_row_header"`
+	Children        []Block `json:"children"`
+}
+
+// TableRowBlock is the payload of table row blocks
+type TableRowBlock struct {
+	Cells [][]RichText `json:"cells"`
+}
+
+// NewParagraph creates a paragraph block
+func NewParagraph(text ...RichText) Block {
+	return Block{Type: BlockTypeParagraph, Payload: &TextBlock{RichText: nonNil(text)}}
+}
+
+// NewHeading creates a heading block; level is clamped to 1-3
+func NewHeading(level int, text ...RichText) Block {
+	blockType := BlockTypeHeading1
+	switch {
+	case level == 2:
+		blockType = BlockTypeHeading2
+	case level >= 3:
+		blockType = BlockTypeHeading3
+	}
+	return Block{Type: blockType, Payload: &HeadingBlock{RichText: nonNil(text)}}
+}
+
+// NewCallout creates a callout block with an optional icon
+func NewCallout(icon *Icon, text ...RichText) Block {
+	return Block{Type: BlockTypeCallout, Payload: &CalloutBlock{RichText: nonNil(text), Icon: icon}}
+}
+
+// NewToggle creates a toggle block with nested children
+func NewToggle(text []RichText, children ...Block) Block {
+	return Block{Type: BlockTypeToggle, Payload: &TextBlock{RichText: nonNil(text), Children: children}}
+}
+
+// NewCode creates a code block in the given language
+func NewCode(language string, text ...RichText) Block {
+	if language == "" {
+		language = "plain text"
+	}
+	return Block{Type: BlockTypeCode, Payload: &CodeBlock{RichText: nonNil(text), Language: language}}
+}
+
+// NewQuote creates a quote block
+func NewQuote(text ...RichText) Block {
+	return Block{Type: BlockTypeQuote, Payload: &TextBlock{RichText: nonNil(text)}}
+}
+
+// NewBulletedListItem creates a bulleted list item block
+func NewBulletedListItem(text ...RichText) Block {
+	return Block{Type: BlockTypeBulletedListItem, Payload: &TextBlock{RichText: nonNil(text)}}
+}
+
+// NewNumberedListItem creates a numbered list item block
+func NewNumberedListItem(text ...RichText) Block {
+	return Block{Type: BlockTypeNumberedListItem, Payload: &TextBlock{RichText: nonNil(text)}}
+}
+
+// NewDivider creates a divider block
+func NewDivider() Block {
+	return Block{Type: BlockTypeDivider, Payload: struct{}{}}
+}
+
+// NewImage creates an image block from a file source
+func NewImage(source FileSource, caption ...RichText) Block {
+	return Block{Type: BlockTypeImage, Payload: &MediaBlock{FileSource: source, Caption: caption}}
+}
+
+// NewVideo creates a video block from a file source (external YouTube and Vimeo URLs play inline)
+func NewVideo(source FileSource, caption ...RichText) Block {
+	return Block{Type: BlockTypeVideo, Payload: &MediaBlock{FileSource: source, Caption: caption}}
+}
+
+// NewFile creates a file block from a file source; name is shown as the file's label
+func NewFile(source FileSource, name string, caption ...RichText) Block {
+	return Block{Type: BlockTypeFile, Payload: &MediaBlock{FileSource: source, Caption: caption, Name: name}}
+}
+
+// NewBookmark creates a bookmark block for a URL
+func NewBookmark(url string, caption ...RichText) Block {
+	return Block{Type: BlockTypeBookmark, Payload: &LinkBlock{URL: url, Caption: caption}}
+}
+
+// NewEmbed creates an embed block for a URL
+func NewEmbed(url string, caption ...RichText) Block {
+	return Block{Type: BlockTypeEmbed, Payload: &LinkBlock{URL: url, Caption: caption}}
+}
+
+// NewTable creates a table block; the width is taken from the widest row
+func NewTable(hasColumnHeader, hasRowHeader bool, rows ...[][]RichText) Block {
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+
+	children := make([]Block, len(rows))
+	for i, row := range rows {
+		// Every row must have exactly table_width cells
+		cells := make([][]RichText, width)
+		for j := range cells {
+			if j < len(row) {
+				cells[j] = nonNil(row[j])
+			} else {
+				cells[j] = []RichText{}
+			}
+		}
+		children[i] = Block{Type: BlockTypeTableRow, Payload: &TableRowBlock{Cells: cells}}
+	}
+
+	return Block{Type: BlockTypeTable, Payload: &TableBlock{
+		TableWidth:      width,
+		HasColumnHeader: hasColumnHeader,
+		HasRowHeader:    hasRowHeader,
+		Children:        children,
+	}}
+}
+
+// nonNil ensures rich text arrays are encoded as [] instead of null
+func nonNil(text []RichText) []RichText {
+	if text == nil {
+		return []RichText{}
+	}
+	return text
+}
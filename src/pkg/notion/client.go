@@ -1,22 +1,44 @@
 package notion
 
 import (
+	"net/http"
+
 	"github.com/jomei/notionapi"
 )
 
 // Client wraps the Notion API client with our configuration
 type Client struct {
 	api          *notionapi.Client
+	raw          *RawClient
 	bookmarksDB  notionapi.DatabaseID
 	tagsDB       notionapi.DatabaseID
 	manualListDB notionapi.DatabaseID
 	smartListDB  notionapi.DatabaseID
 }
 
+// ClientOptions holds the optional settings of a Client
+type ClientOptions struct {
+	// Transport sends every Notion API request (nil = http.DefaultTransport)
+	Transport http.RoundTripper
+}
+
 // NewClient creates a new Notion client with the provided configuration
 func NewClient(apiKey, bookmarksDBID, tagsDBID, manualListDBID, smartListDBID string) *Client {
+	return NewClientWithOptions(apiKey, bookmarksDBID, tagsDBID, manualListDBID, smartListDBID, ClientOptions{})
+}
+
+// NewClientWithOptions creates a Notion client whose requests go through a custom transport
+func NewClientWithOptions(apiKey, bookmarksDBID, tagsDBID, manualListDBID, smartListDBID string, options ClientOptions) *Client {
+	var apiOptions []notionapi.ClientOption
+	raw := NewRawClient(apiKey)
+	if options.Transport != nil {
+		apiOptions = append(apiOptions, notionapi.WithHTTPClient(&http.Client{Transport: options.Transport}))
+		raw.httpClient.Transport = options.Transport
+	}
+
 	return &Client{
-		api:          notionapi.NewClient(notionapi.Token(apiKey)),
+		api:          notionapi.NewClient(notionapi.Token(apiKey), apiOptions...),
+		raw:          raw,
 		bookmarksDB:  notionapi.DatabaseID(bookmarksDBID),
 		tagsDB:       notionapi.DatabaseID(tagsDBID),
 		manualListDB: notionapi.DatabaseID(manualListDBID),
@@ -29,6 +51,11 @@ func (c *Client) API() *notionapi.Client {
 	return c.api
 }
 
+// Raw returns the raw HTTP client used for endpoints the notionapi library does not cover
+func (c *Client) Raw() *RawClient {
+	return c.raw
+}
+
 // BookmarksDB returns the Bookmarks database ID
 func (c *Client) BookmarksDB() notionapi.DatabaseID {
 	return c.bookmarksDB
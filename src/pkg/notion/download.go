@@ -0,0 +1,39 @@
+package notion
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/httpfetch"
+)
+
+// downloadedImage is an image fetched in-process for a direct upload
+type downloadedImage struct {
+	data        []byte
+	contentType string
+}
+
+// downloadImage fetches an image for a direct upload, sending the page as Referer
+func downloadImage(ctx context.Context, fetcher *httpfetch.Fetcher, imageURL, referer, accept string) (*downloadedImage, error) {
+	resp, err := fetcher.Get(ctx, imageURL, referer, accept)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := fetcher.ReadBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("image is empty")
+	}
+
+	contentType := httpfetch.ImageContentType(resp.Header.Get("Content-Type"), data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("unexpected content type %q", contentType)
+	}
+
+	return &downloadedImage{data: data, contentType: contentType}, nil
+}
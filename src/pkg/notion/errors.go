@@ -2,6 +2,7 @@ package notion
 
 import (
 	"fmt"
+	"time"
 )
 
 // Error types for common Notion API errors
@@ -18,6 +19,11 @@ type NotionError struct {
 	Operation string
 	Err       error
 	Message   string
+
+	// Set for errors decoded from raw API responses
+	StatusCode int
+	Code       string
+	RetryAfter time.Duration
 }
 
 func (e *NotionError) Error() string {
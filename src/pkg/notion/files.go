@@ -0,0 +1,121 @@
+package notion
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// maxSinglePartBytes is the largest file Notion accepts in single_part mode
+	maxSinglePartBytes = 20 * 1024 * 1024
+	// multiPartChunkBytes is the part size used for multi_part uploads (Notion accepts 5-20MB parts)
+	multiPartChunkBytes = 10 * 1024 * 1024
+)
+
+// NamedFile is an entry in a Files & media property
+type NamedFile struct {
+	Name string `json:"name"`
+	FileSource
+}
+
+// UploadFile uploads file contents to Notion storage using the File Upload API
+// Files up to 20MB are sent in single_part mode, larger files in multi_part mode
+// Returns the uploaded FileUploadObject, which can be attached to pages, blocks and properties
+func (c *Client) UploadFile(ctx context.Context, filename, contentType string, data []byte) (*FileUploadObject, error) {
+	if len(data) == 0 {
+		return nil, NewError("upload file", ErrInvalidInput, "file is empty")
+	}
+
+	if len(data) > maxSinglePartBytes {
+		return c.uploadMultiPart(ctx, filename, contentType, data)
+	}
+
+	// Step 1: Create FileUpload object
+	reqBody := CreateFileUploadRequest{
+		Mode:        FileUploadModeSinglePart,
+		Filename:    filename,
+		ContentType: contentType,
+	}
+
+	var fileUpload FileUploadObject
+	if err := c.raw.Do(ctx, "create file upload", http.MethodPost, "/file_uploads", reqBody, &fileUpload); err != nil {
+		return nil, err
+	}
+
+	// Step 2: Send the file contents
+	var sent FileUploadObject
+	path := fmt.Sprintf("/file_uploads/%s/send", fileUpload.ID)
+	if err := c.raw.DoMultipart(ctx, "send file upload", path, nil, filename, contentType, data, &sent); err != nil {
+		return nil, err
+	}
+
+	if sent.Status != FileUploadStatusUploaded {
+		return nil, NewError("send file upload", ErrAPIError, fmt.Sprintf("unexpected upload status: %s", sent.Status))
+	}
+
+	return &sent, nil
+}
+
+// uploadMultiPart uploads a large file in parts and completes the upload
+func (c *Client) uploadMultiPart(ctx context.Context, filename, contentType string, data []byte) (*FileUploadObject, error) {
+	parts := (len(data) + multiPartChunkBytes - 1) / multiPartChunkBytes
+
+	// Step 1: Create FileUpload object
+	reqBody := CreateFileUploadRequest{
+		Mode:          FileUploadModeMultiPart,
+		Filename:      filename,
+		ContentType:   contentType,
+		NumberOfParts: parts,
+	}
+
+	var fileUpload FileUploadObject
+	if err := c.raw.Do(ctx, "create file upload", http.MethodPost, "/file_uploads", reqBody, &fileUpload); err != nil {
+		return nil, err
+	}
+
+	// Step 2: Send each part (part numbers start at 1)
+	path := fmt.Sprintf("/file_uploads/%s/send", fileUpload.ID)
+	for part := 1; part <= parts; part++ {
+		start := (part - 1) * multiPartChunkBytes
+		end := start + multiPartChunkBytes
+		if end > len(data) {
+			end = len(data)
+		}
+
+		fields := map[string]string{"part_number": strconv.Itoa(part)}
+		if err := c.raw.DoMultipart(ctx, "send file upload part", path, fields, filename, contentType, data[start:end], nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// Step 3: Complete the upload
+	var completed FileUploadObject
+	completePath := fmt.Sprintf("/file_uploads/%s/complete", fileUpload.ID)
+	if err := c.raw.Do(ctx, "complete file upload", http.MethodPost, completePath, nil, &completed); err != nil {
+		return nil, err
+	}
+
+	if completed.Status != FileUploadStatusUploaded {
+		return nil, NewError("complete file upload", ErrAPIError, fmt.Sprintf("unexpected upload status: %s", completed.Status))
+	}
+
+	return &completed, nil
+}
+
+// SetFilesProperty replaces the contents of a Files & media property on a page
+func (c *Client) SetFilesProperty(ctx context.Context, pageID, property string, files ...NamedFile) error {
+	if files == nil {
+		files = []NamedFile{}
+	}
+
+	update := PageUpdate{
+		Properties: map[string]interface{}{
+			property: map[string]interface{}{
+				"files": files,
+			},
+		},
+	}
+	return c.UpdatePage(ctx, pageID, update)
+}
@@ -1,110 +1,118 @@
 package notion
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+
+	"github.com/jomei/notionapi"
 )
 
+// blockChildrenResponse is a page of results from the block children endpoint
+type blockChildrenResponse struct {
+	Results []struct {
+		ID string `json:"id"`
+	} `json:"results"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor"`
+}
+
 // UpdatePageContentWithJSON replaces all content in a Notion page with a code block containing the provided JSON string.
 // This erases all existing content before adding the new code block.
-func UpdatePageContentWithJSON(ctx context.Context, apiKey, pageID, jsonContent string) error {
-	client := &http.Client{}
+func (c *Client) UpdatePageContentWithJSON(ctx context.Context, pageID, jsonContent string) error {
+	return c.ReplacePageContent(ctx, pageID, NewCode("json", SplitText(jsonContent)...))
+}
 
+// ReplacePageContent erases all existing content in a page and appends the provided blocks
+func (c *Client) ReplacePageContent(ctx context.Context, pageID string, blocks ...Block) error {
 	// Step 1: Get existing children
-	getURL := fmt.Sprintf("https://api.notion.com/v1/blocks/%s/children", pageID)
-	req, err := http.NewRequestWithContext(ctx, "GET", getURL, nil)
+	childIDs, err := c.listChildIDs(ctx, pageID)
 	if err != nil {
-		return fmt.Errorf("failed to create get children request: %w", err)
+		return err
 	}
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-	req.Header.Set("Notion-Version", "2022-06-28")
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to get children: %w", err)
+	// Step 2: Delete all existing children
+	for _, childID := range childIDs {
+		err := c.raw.Do(ctx, "delete block", http.MethodDelete, "/blocks/"+childID, nil, nil)
+		if err != nil {
+			return err
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("get children failed with status %d", resp.StatusCode)
-	}
+	// Step 3: Append the new blocks
+	return c.AppendBlocks(ctx, pageID, blocks...)
+}
 
-	var childrenResp struct {
-		Results []struct {
-			ID string `json:"id"`
-		} `json:"results"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&childrenResp); err != nil {
-		return fmt.Errorf("failed to decode children response: %w", err)
+// AppendBlocks appends blocks to the end of a page or block
+func (c *Client) AppendBlocks(ctx context.Context, pageID string, blocks ...Block) error {
+	if len(blocks) == 0 {
+		return nil
 	}
 
-	// Step 2: Delete all existing children
-	for _, child := range childrenResp.Results {
-		deleteURL := fmt.Sprintf("https://api.notion.com/v1/blocks/%s", child.ID)
-		req, err := http.NewRequestWithContext(ctx, "DELETE", deleteURL, nil)
-		if err != nil {
-			return fmt.Errorf("failed to create delete request for %s: %w", child.ID, err)
+	body := AppendChildrenRequest{Children: blocks}
+	return c.raw.Do(ctx, "append blocks", http.MethodPatch, fmt.Sprintf("/blocks/%s/children", pageID), body, nil)
+}
+
+// listChildIDs returns the IDs of all top-level blocks in a page, following pagination
+func (c *Client) listChildIDs(ctx context.Context, pageID string) ([]string, error) {
+	var ids []string
+	cursor := ""
+
+	for {
+		path := fmt.Sprintf("/blocks/%s/children?page_size=100", pageID)
+		if cursor != "" {
+			path += "&start_cursor=" + url.QueryEscape(cursor)
 		}
-		req.Header.Set("Authorization", "Bearer "+apiKey)
-		req.Header.Set("Notion-Version", "2022-06-28")
 
-		resp, err := client.Do(req)
-		if err != nil {
-			return fmt.Errorf("failed to delete block %s: %w", child.ID, err)
+		var resp blockChildrenResponse
+		if err := c.raw.Do(ctx, "get block children", http.MethodGet, path, nil, &resp); err != nil {
+			return nil, err
 		}
-		resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("delete block %s failed with status %d", child.ID, resp.StatusCode)
+		for _, child := range resp.Results {
+			ids = append(ids, child.ID)
 		}
-	}
 
-	// Step 3: Append the new code block
-	appendURL := fmt.Sprintf("https://api.notion.com/v1/blocks/%s/children", pageID)
-	block := map[string]interface{}{
-		"type": "code",
-		"code": map[string]interface{}{
-			"rich_text": []map[string]interface{}{
-				{
-					"type": "text",
-					"text": map[string]interface{}{
-						"content": jsonContent,
-					},
-				},
-			},
-			"language": "json",
-		},
-	}
-	body := map[string]interface{}{
-		"children": []map[string]interface{}{block},
-	}
-	bodyJSON, err := json.Marshal(body)
-	if err != nil {
-		return fmt.Errorf("failed to marshal append body: %w", err)
+		if !resp.HasMore || resp.NextCursor == "" {
+			return ids, nil
+		}
+		cursor = resp.NextCursor
 	}
+}
 
-	req, err = http.NewRequestWithContext(ctx, "PATCH", appendURL, bytes.NewBuffer(bodyJSON))
-	if err != nil {
-		return fmt.Errorf("failed to create append request: %w", err)
-	}
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-	req.Header.Set("Notion-Version", "2022-06-28")
-	req.Header.Set("Content-Type", "application/json")
+// UpdatePage sends a raw page update (cover, icon or properties)
+func (c *Client) UpdatePage(ctx context.Context, pageID string, update PageUpdate) error {
+	return c.raw.Do(ctx, "update page", http.MethodPatch, "/pages/"+pageID, update, nil)
+}
 
-	resp, err = client.Do(req)
+// PageImages returns the URLs of a page's current cover and icon ("" when unset or an emoji)
+// Notion-hosted files have signed URLs that expire after an hour
+func (c *Client) PageImages(ctx context.Context, pageID string) (string, string, error) {
+	page, err := c.api.Page.Get(ctx, notionapi.PageID(pageID))
 	if err != nil {
-		return fmt.Errorf("failed to append block: %w", err)
+		return "", "", NewError("get page", err, "")
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("append block failed with status %d: %s", resp.StatusCode, string(body))
+	var cover, icon string
+	if page.Cover != nil {
+		cover = page.Cover.GetURL()
+	}
+	if page.Icon != nil {
+		icon = page.Icon.GetURL()
 	}
+	return cover, icon, nil
+}
+
+// SetPageCover sets the cover of a Notion page using a FileUpload ID
+func (c *Client) SetPageCover(ctx context.Context, pageID string, fileUploadID string) error {
+	// Raw request since the library doesn't support file_upload type yet
+	cover := FileUploadSource(fileUploadID)
+	return c.UpdatePage(ctx, pageID, PageUpdate{Cover: &cover})
+}
 
-	return nil
+// SetPageIcon sets the icon of a Notion page using a FileUpload ID
+func (c *Client) SetPageIcon(ctx context.Context, pageID string, fileUploadID string) error {
+	// Raw request since the library doesn't support file_upload type yet
+	return c.UpdatePage(ctx, pageID, PageUpdate{Icon: FileIcon(FileUploadSource(fileUploadID))})
 }
@@ -0,0 +1,159 @@
+package notion
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// databaseResponse is the part of a database object describing its properties
+type databaseResponse struct {
+	Properties map[string]struct {
+		Type string `json:"type"`
+	} `json:"properties"`
+}
+
+// DatabaseProperties returns the type of every property of a database (e.g. "Stars" -> "number")
+func (c *Client) DatabaseProperties(ctx context.Context, databaseID string) (map[string]string, error) {
+	var resp databaseResponse
+	if err := c.raw.Do(ctx, "get database", http.MethodGet, "/databases/"+databaseID, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]string, len(resp.Properties))
+	for name, property := range resp.Properties {
+		types[name] = property.Type
+	}
+	return types, nil
+}
+
+// SetProperties updates page properties from values already built with PropertyValue
+func (c *Client) SetProperties(ctx context.Context, pageID string, properties map[string]interface{}) error {
+	if len(properties) == 0 {
+		return nil
+	}
+	return c.UpdatePage(ctx, pageID, PageUpdate{Properties: properties})
+}
+
+// PropertyValue converts a Go value (string, float64, int, []string, time.Time or bool)
+// into the JSON value of a property of the given type
+func PropertyValue(propertyType string, value interface{}) (interface{}, error) {
+	switch propertyType {
+	case "title", "rich_text":
+		return map[string]interface{}{
+			propertyType: []RichText{Text(truncateRunes(formatValue(value), maxRichTextLength))},
+		}, nil
+	case "number":
+		number, err := numberValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"number": number}, nil
+	case "select":
+		name := formatValue(value)
+		if list, ok := value.([]string); ok && len(list) > 0 {
+			name = list[0]
+		}
+		return map[string]interface{}{"select": selectOption(name)}, nil
+	case "multi_select":
+		items, ok := value.([]string)
+		if !ok {
+			items = []string{formatValue(value)}
+		}
+		options := make([]map[string]string, 0, len(items))
+		for _, item := range items {
+			if strings.TrimSpace(item) != "" {
+				options = append(options, selectOption(item))
+			}
+		}
+		return map[string]interface{}{"multi_select": options}, nil
+	case "url":
+		return map[string]interface{}{"url": formatValue(value)}, nil
+	case "date":
+		start, err := dateValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"date": map[string]string{"start": start}}, nil
+	case "checkbox":
+		checked, err := strconv.ParseBool(formatValue(value))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", formatValue(value))
+		}
+		return map[string]interface{}{"checkbox": checked}, nil
+	default:
+		return nil, fmt.Errorf("property type %q is not supported", propertyType)
+	}
+}
+
+// formatValue renders a value as text; lists are joined with commas
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ", ")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// numberValue converts a value to a number
+func numberValue(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case int:
+		return float64(v), nil
+	default:
+		number, err := strconv.ParseFloat(strings.TrimSpace(formatValue(value)), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", formatValue(value))
+		}
+		return number, nil
+	}
+}
+
+// dateValue converts a value to a Notion date start (a date when there is no time of day)
+func dateValue(value interface{}) (string, error) {
+	t, ok := value.(time.Time)
+	if !ok {
+		text := strings.TrimSpace(formatValue(value))
+		var err error
+		if t, err = time.Parse(time.RFC3339, text); err != nil {
+			if t, err = time.Parse(time.DateOnly, text); err != nil {
+				return "", fmt.Errorf("%q is not a date", text)
+			}
+		}
+	}
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format(time.DateOnly), nil
+	}
+	return t.Format(time.RFC3339), nil
+}
+
+// selectOption builds a select option; Notion rejects commas in option names
+func selectOption(name string) map[string]string {
+	name = strings.TrimSpace(strings.ReplaceAll(name, ",", " "))
+	return map[string]string{"name": truncateRunes(name, 100)}
+}
+
+// truncateRunes shortens text to at most limit characters
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,272 @@
+package notion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// apiBaseURL is the base URL for all raw Notion API requests
+	apiBaseURL = "https://api.notion.com/v1"
+	// apiVersion is the Notion-Version header sent with every raw request
+	apiVersion = "2022-06-28"
+
+	defaultRawTimeout    = 30 * time.Second
+	defaultRawMaxRetries = 3
+	defaultRawRetryDelay = 500 * time.Millisecond
+	maxRawRetryDelay     = 30 * time.Second
+)
+
+// RawClient sends requests to Notion endpoints that the notionapi library does not cover
+// (file uploads, file_upload covers and icons, block children with typed builders).
+// It owns authentication, versioning, JSON encoding, error decoding and retries.
+type RawClient struct {
+	token      string
+	baseURL    string
+	version    string
+	httpClient *http.Client
+	maxRetries int
+	retryDelay time.Duration
+}
+
+// NewRawClient creates a new raw Notion HTTP client
+func NewRawClient(token string) *RawClient {
+	return &RawClient{
+		token:      token,
+		baseURL:    apiBaseURL,
+		version:    apiVersion,
+		httpClient: &http.Client{Timeout: defaultRawTimeout},
+		maxRetries: defaultRawMaxRetries,
+		retryDelay: defaultRawRetryDelay,
+	}
+}
+
+// apiErrorResponse is the error body returned by the Notion API
+type apiErrorResponse struct {
+	Object  string `json:"object"`
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Do sends a JSON request to the given API path (e.g. "/pages/<id>") and decodes
+// the JSON response into out. body and out may be nil.
+func (r *RawClient) Do(ctx context.Context, operation, method, path string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return NewError(operation, ErrInvalidInput, fmt.Sprintf("failed to marshal request body: %v", err))
+		}
+	}
+
+	contentType := ""
+	if payload != nil {
+		contentType = "application/json"
+	}
+
+	return r.send(ctx, operation, method, path, contentType, payload, out)
+}
+
+// DoMultipart sends a multipart/form-data request with a single file part named "file"
+// plus optional form fields, and decodes the JSON response into out.
+// Used to send file contents to the File Upload API.
+func (r *RawClient) DoMultipart(ctx context.Context, operation, path string, fields map[string]string, filename, contentType string, data []byte, out interface{}) error {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return NewError(operation, err, "failed to write multipart field")
+		}
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, escapeQuotes(filename)))
+	header.Set("Content-Type", contentType)
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return NewError(operation, err, "failed to create multipart body")
+	}
+	if _, err := part.Write(data); err != nil {
+		return NewError(operation, err, "failed to write multipart body")
+	}
+	if err := writer.Close(); err != nil {
+		return NewError(operation, err, "failed to close multipart body")
+	}
+
+	return r.send(ctx, operation, http.MethodPost, path, writer.FormDataContentType(), buf.Bytes(), out)
+}
+
+// send performs the request with retries for rate limits; server errors and network failures
+// are only retried for idempotent methods, since a POST or PATCH may already have been applied
+func (r *RawClient) send(ctx context.Context, operation, method, path, contentType string, payload []byte, out interface{}) error {
+	var lastErr error
+
+	for attempt := 0; attempt <= r.maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, r.backoff(attempt, lastErr)); err != nil {
+				return NewError(operation, err, "")
+			}
+		}
+
+		retry, err := r.sendOnce(ctx, operation, method, path, contentType, payload, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+
+		if !retry || ctx.Err() != nil {
+			return err
+		}
+	}
+
+	return lastErr
+}
+
+// sendOnce performs a single request and reports whether a failure is worth retrying
+func (r *RawClient) sendOnce(ctx context.Context, operation, method, path, contentType string, payload []byte, out interface{}) (bool, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, r.baseURL+path, reqBody)
+	if err != nil {
+		return false, NewError(operation, err, "failed to create request")
+	}
+
+	req.Header.Set("Authorization", "Bearer "+r.token)
+	req.Header.Set("Notion-Version", r.version)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	idempotent := isIdempotent(method)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return idempotent, NewError(operation, err, "request failed")
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return idempotent, NewError(operation, err, "failed to read response body")
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := decodeAPIError(operation, resp.StatusCode, respBody)
+		if notionErr, ok := apiErr.(*NotionError); ok {
+			notionErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+		// Rate-limited requests were rejected before being applied, so they are safe to repeat
+		retry := resp.StatusCode == http.StatusTooManyRequests || (idempotent && isRetryableStatus(resp.StatusCode))
+		return retry, apiErr
+	}
+
+	if out != nil && len(respBody) > 0 {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return false, NewError(operation, err, "failed to decode response")
+		}
+	}
+
+	return false, nil
+}
+
+// backoff returns how long to wait before the given retry attempt
+func (r *RawClient) backoff(attempt int, lastErr error) time.Duration {
+	var notionErr *NotionError
+	if errors.As(lastErr, &notionErr) && notionErr.RetryAfter > 0 {
+		return min(notionErr.RetryAfter, maxRawRetryDelay)
+	}
+	return min(r.retryDelay*time.Duration(1<<(attempt-1)), maxRawRetryDelay)
+}
+
+// decodeAPIError converts a non-2xx response into a NotionError wrapping one of the sentinel errors
+func decodeAPIError(operation string, status int, body []byte) error {
+	var apiErr apiErrorResponse
+	if err := json.Unmarshal(body, &apiErr); err != nil || apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	var sentinel error
+	switch {
+	case status == http.StatusNotFound || apiErr.Code == "object_not_found":
+		sentinel = ErrNotFound
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		sentinel = ErrUnauthorized
+	case status == http.StatusTooManyRequests:
+		sentinel = ErrRateLimited
+	case status == http.StatusBadRequest:
+		sentinel = ErrInvalidInput
+	default:
+		sentinel = ErrAPIError
+	}
+
+	return &NotionError{
+		Operation:  operation,
+		Err:        sentinel,
+		Message:    fmt.Sprintf("status %d %s: %s", status, apiErr.Code, apiErr.Message),
+		StatusCode: status,
+		Code:       apiErr.Code,
+	}
+}
+
+// isIdempotent reports whether repeating a request with the method has no further effect
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isRetryableStatus reports whether a response status is transient
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// escapeQuotes escapes a filename for use in a Content-Disposition header
+func escapeQuotes(s string) string {
+	return strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace(s)
+}
+
+// sleepContext waits for the given duration or until the context is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -80,6 +80,10 @@ type FileUploadMode string
 const (
 	// FileUploadModeExternalURL imports a file from an external URL
 	FileUploadModeExternalURL FileUploadMode = "external_url"
+	// FileUploadModeSinglePart uploads file contents in a single request (up to 20MB)
+	FileUploadModeSinglePart FileUploadMode = "single_part"
+	// FileUploadModeMultiPart uploads file contents in several parts (files larger than 20MB)
+	FileUploadModeMultiPart FileUploadMode = "multi_part"
 )
 
 // FileUploadStatus represents the status of a file upload
@@ -97,8 +101,11 @@ const (
 // CreateFileUploadRequest is the request body for creating a file upload
 type CreateFileUploadRequest struct {
 	Mode        FileUploadMode `json:"mode"`
-	ExternalURL string         `json:"external_url"`
+	ExternalURL string         `json:"external_url,omitempty"`
 	Filename    string         `json:"filename"`
+	ContentType string         `json:"content_type,omitempty"`
+	// NumberOfParts is required for multi_part uploads
+	NumberOfParts int `json:"number_of_parts,omitempty"`
 }
 
 // FileUploadObject represents a file upload response from the Notion API
@@ -1,40 +1,286 @@
 package notion
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"path"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/httpfetch"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/uploadcache"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/urlpolicy"
 )
 
+// UploadMode selects how images are transferred to Notion storage
+type UploadMode string
+
+const (
+	// UploadModeExternal lets Notion fetch the image itself (mode: "external_url")
+	UploadModeExternal UploadMode = "external"
+	// UploadModeDirect downloads the image locally and sends the bytes (mode: "single_part"/"multi_part")
+	UploadModeDirect UploadMode = "direct"
+	// UploadModeAuto tries the external import first and falls back to a direct upload
+	UploadModeAuto UploadMode = "auto"
+)
+
+// ParseUploadMode parses an upload mode name, returning an error for unknown modes
+func ParseUploadMode(value string) (UploadMode, error) {
+	switch mode := UploadMode(strings.ToLower(strings.TrimSpace(value))); mode {
+	case UploadModeExternal, UploadModeDirect, UploadModeAuto:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown upload mode %q (expected external, direct or auto)", value)
+	}
+}
+
+// ImageUploaderOptions holds the optional settings of an ImageUploader
+type ImageUploaderOptions struct {
+	// Mode defaults to UploadModeExternal
+	Mode UploadMode
+	// MaxDownloadBytes limits the size of images downloaded in direct mode (0 = 50MB)
+	MaxDownloadBytes int64
+	// DownloadTimeout limits each direct-mode download (0 = 30s)
+	DownloadTimeout time.Duration
+	// Transformer converts downloaded images before a direct upload (nil = upload as-is)
+	Transformer ImageTransformer
+	// Cache reuses uploads of images already attached to another page (nil = always upload)
+	Cache *uploadcache.Cache
+	// Policy restricts which URLs are downloaded or handed to Notion's importer (nil = no restrictions)
+	Policy *urlpolicy.Policy
+	// Transport sends the direct-mode downloads, e.g. to record or replay them (nil = the policy's transport)
+	Transport http.RoundTripper
+}
+
+// ImageTransformer converts downloaded image bytes before they are uploaded
+// icon is true for page icons. It returns the new bytes and their content type.
+type ImageTransformer func(data []byte, contentType string, icon bool) ([]byte, string, error)
+
 // ImageUploader handles uploading images to Notion storage
 type ImageUploader struct {
-	notionToken  string
-	httpClient   *http.Client
+	client       *Client
 	timeout      time.Duration
 	pollInterval time.Duration
+	mode         UploadMode
+	fetcher      *httpfetch.Fetcher
+	transformer  ImageTransformer
+	cache        *uploadcache.Cache
+	policy       *urlpolicy.Policy
+
+	// pending holds cache entries for uploads until they are attached to a page
+	pendingMu sync.Mutex
+	pending   map[string]pendingUpload
+}
+
+// pendingUpload is an upload returned to the caller but not yet attached
+type pendingUpload struct {
+	entry  uploadcache.Entry
+	reused bool
 }
 
 // NewImageUploader creates a new image uploader with the specified configuration
-func NewImageUploader(token string, timeout time.Duration, pollInterval time.Duration) *ImageUploader {
+func NewImageUploader(client *Client, timeout time.Duration, pollInterval time.Duration, options ImageUploaderOptions) *ImageUploader {
+	mode := options.Mode
+	if mode == "" {
+		mode = UploadModeExternal
+	}
+
 	return &ImageUploader{
-		notionToken:  token,
-		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		client:       client,
 		timeout:      timeout,
 		pollInterval: pollInterval,
+		mode:         mode,
+		fetcher: httpfetch.NewWithOptions(httpfetch.Options{
+			Timeout:   options.DownloadTimeout,
+			MaxBytes:  options.MaxDownloadBytes,
+			Policy:    options.Policy,
+			Transport: options.Transport,
+		}),
+		transformer: options.Transformer,
+		cache:       options.Cache,
+		policy:      options.Policy,
+		pending:     make(map[string]pendingUpload),
 	}
 }
 
+// Mode returns the configured upload mode
+func (u *ImageUploader) Mode() UploadMode {
+	return u.mode
+}
+
 // UploadImageFromURL uploads an image from an external URL to Notion storage
-// Uses the Indirect Import method (mode: "external_url")
 // Returns fileUploadID on success, error on failure
 func (u *ImageUploader) UploadImageFromURL(ctx context.Context, imageURL string) (string, error) {
+	return u.UploadImageFromPage(ctx, imageURL, "")
+}
+
+// UploadImageFromPage uploads an image found on pageURL to Notion storage
+// The page URL is sent as the Referer when the image is downloaded in direct mode
+// Returns fileUploadID on success, error on failure
+func (u *ImageUploader) UploadImageFromPage(ctx context.Context, imageURL, pageURL string) (string, error) {
+	return u.upload(ctx, imageURL, pageURL, false)
+}
+
+// UploadIconFromPage uploads a favicon found on pageURL to Notion storage
+// In direct mode the transformer receives icon=true so it can produce a square icon
+func (u *ImageUploader) UploadIconFromPage(ctx context.Context, iconURL, pageURL string) (string, error) {
+	return u.upload(ctx, iconURL, pageURL, true)
+}
+
+// UploadImageData uploads an image generated in-process (e.g. a placeholder cover) to Notion storage
+// Returns fileUploadID on success, error on failure
+func (u *ImageUploader) UploadImageData(ctx context.Context, filename, contentType string, data []byte) (string, error) {
+	fileUpload, err := u.client.UploadFile(ctx, filename, contentType, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image: %w", err)
+	}
+	return fileUpload.ID, nil
+}
+
+// Reused reports whether a file upload ID returned by the uploader came from the cache
+func (u *ImageUploader) Reused(fileUploadID string) bool {
+	u.pendingMu.Lock()
+	defer u.pendingMu.Unlock()
+	return u.pending[fileUploadID].reused
+}
+
+// MarkAttached records in the cache that an upload was attached to pageID,
+// so later bookmarks with the same image reuse it
+func (u *ImageUploader) MarkAttached(fileUploadID, pageID string) error {
+	u.pendingMu.Lock()
+	pending, ok := u.pending[fileUploadID]
+	delete(u.pending, fileUploadID)
+	u.pendingMu.Unlock()
+
+	// Entries served by URL are already cached; hash hits still add their new URL
+	if !ok || u.cache == nil || pending.reused && pending.entry.PageID != "" {
+		return nil
+	}
+
+	entry := pending.entry
+	entry.PageID = pageID
+	return u.cache.Put(entry)
+}
+
+// CopyFromSourcePage imports the cover or icon of the page a cached upload was first attached to,
+// for when Notion refuses to attach the cached upload again. The image is copied from Notion's
+// storage, so its original URL isn't fetched again.
+func (u *ImageUploader) CopyFromSourcePage(ctx context.Context, fileUploadID string) (string, error) {
+	u.pendingMu.Lock()
+	pending, ok := u.pending[fileUploadID]
+	u.pendingMu.Unlock()
+	if !ok || pending.entry.PageID == "" {
+		return "", fmt.Errorf("no page to copy from")
+	}
+
+	cover, icon, err := u.client.PageImages(ctx, pending.entry.PageID)
+	if err != nil {
+		return "", err
+	}
+	source := cover
+	if pending.entry.Kind == uploadcache.KindIcon {
+		source = icon
+	}
+	if source == "" {
+		return "", fmt.Errorf("page %s has no %s to copy", pending.entry.PageID, pending.entry.Kind)
+	}
+
+	fileUpload, err := u.createFileUpload(ctx, source, extractFilenameFromURL(pending.entry.URL))
+	if err != nil {
+		return "", fmt.Errorf("failed to create file upload: %w", err)
+	}
+	copiedID, err := u.pollForCompletion(ctx, fileUpload.ID)
+	if err != nil {
+		return "", fmt.Errorf("copy failed or timed out: %w", err)
+	}
+
+	// Cached again under the original image, so later bookmarks reuse the copy
+	entry := pending.entry
+	entry.FileUploadID = copiedID
+	entry.PageID = ""
+	u.track(entry, false)
+	return copiedID, nil
+}
+
+// Discard forgets uploads that will not be attached, so they aren't cached
+func (u *ImageUploader) Discard(fileUploadIDs ...string) {
+	u.pendingMu.Lock()
+	defer u.pendingMu.Unlock()
+	for _, fileUploadID := range fileUploadIDs {
+		delete(u.pending, fileUploadID)
+	}
+}
+
+// Invalidate forgets a cached upload that Notion refused to attach
+func (u *ImageUploader) Invalidate(fileUploadID string) error {
+	u.pendingMu.Lock()
+	delete(u.pending, fileUploadID)
+	u.pendingMu.Unlock()
+
+	if u.cache == nil {
+		return nil
+	}
+	return u.cache.Forget(fileUploadID)
+}
+
+// upload returns a cached upload for the image URL or dispatches an upload according to the configured mode
+func (u *ImageUploader) upload(ctx context.Context, imageURL, pageURL string, icon bool) (string, error) {
+	if u.cache != nil {
+		if entry, ok := u.cache.LookupURL(cacheKind(icon), imageURL); ok {
+			u.track(entry, true)
+			return entry.FileUploadID, nil
+		}
+	}
+
+	switch u.mode {
+	case UploadModeDirect:
+		return u.uploadDirect(ctx, imageURL, pageURL, icon)
+	case UploadModeAuto:
+		fileUploadID, err := u.uploadExternal(ctx, imageURL, icon)
+		if err == nil {
+			return fileUploadID, nil
+		}
+		fileUploadID, directErr := u.uploadDirect(ctx, imageURL, pageURL, icon)
+		if directErr != nil {
+			return "", fmt.Errorf("external import failed (%v), direct upload failed: %w", err, directErr)
+		}
+		return fileUploadID, nil
+	default:
+		return u.uploadExternal(ctx, imageURL, icon)
+	}
+}
+
+// track remembers the cache entry for an upload until it is attached
+func (u *ImageUploader) track(entry uploadcache.Entry, reused bool) {
+	if u.cache == nil {
+		return
+	}
+	u.pendingMu.Lock()
+	defer u.pendingMu.Unlock()
+	u.pending[entry.FileUploadID] = pendingUpload{entry: entry, reused: reused}
+}
+
+// cacheKind returns the cache namespace for covers or icons
+func cacheKind(icon bool) uploadcache.Kind {
+	if icon {
+		return uploadcache.KindIcon
+	}
+	return uploadcache.KindCover
+}
+
+// uploadExternal uses the Indirect Import method (mode: "external_url")
+func (u *ImageUploader) uploadExternal(ctx context.Context, imageURL string, icon bool) (string, error) {
+	// Notion fetches the URL itself, so the host is resolved and checked here first
+	if u.policy != nil {
+		if err := u.policy.Check(ctx, imageURL); err != nil {
+			return "", err
+		}
+	}
+
 	// Extract filename from URL
 	filename := extractFilenameFromURL(imageURL)
 
@@ -50,45 +296,59 @@ func (u *ImageUploader) UploadImageFromURL(ctx context.Context, imageURL string)
 		return "", fmt.Errorf("upload failed or timed out: %w", err)
 	}
 
+	u.track(uploadcache.Entry{Kind: cacheKind(icon), URL: imageURL, FileUploadID: fileUploadID}, false)
 	return fileUploadID, nil
 }
 
-// createFileUpload creates a new file upload request in Notion
-func (u *ImageUploader) createFileUpload(ctx context.Context, externalURL, filename string) (*FileUploadObject, error) {
-	reqBody := CreateFileUploadRequest{
-		Mode:        FileUploadModeExternalURL,
-		ExternalURL: externalURL,
-		Filename:    filename,
+// uploadDirect downloads the image in-process and sends the bytes with the File Upload API
+func (u *ImageUploader) uploadDirect(ctx context.Context, imageURL, pageURL string, icon bool) (string, error) {
+	accept := httpfetch.AcceptImage
+	if u.transformer != nil {
+		// Formats the transformer cannot decode are refused up front
+		accept = httpfetch.AcceptDecodableImage
 	}
 
-	bodyBytes, err := json.Marshal(reqBody)
+	image, err := downloadImage(ctx, u.fetcher, imageURL, pageURL, accept)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to download image: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.notion.com/v1/file_uploads",
-		bytes.NewReader(bodyBytes))
-	if err != nil {
-		return nil, err
+	// The same image is often served under several URLs (CDN variants, per-page favicon paths)
+	hash := uploadcache.HashContent(image.data)
+	if u.cache != nil {
+		if entry, ok := u.cache.LookupHash(cacheKind(icon), hash); ok {
+			u.track(uploadcache.Entry{Kind: entry.Kind, URL: imageURL, ContentHash: hash, FileUploadID: entry.FileUploadID}, true)
+			return entry.FileUploadID, nil
+		}
 	}
 
-	req.Header.Set("Authorization", "Bearer "+u.notionToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Notion-Version", "2022-06-28")
+	data, contentType := image.data, image.contentType
+	if u.transformer != nil {
+		data, contentType, err = u.transformer(data, contentType, icon)
+		if err != nil {
+			return "", fmt.Errorf("failed to transcode image: %w", err)
+		}
+	}
 
-	resp, err := u.httpClient.Do(req)
+	fileUpload, err := u.client.UploadFile(ctx, filenameForContentType(imageURL, contentType), contentType, data)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to upload image: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+	u.track(uploadcache.Entry{Kind: cacheKind(icon), URL: imageURL, ContentHash: hash, FileUploadID: fileUpload.ID}, false)
+	return fileUpload.ID, nil
+}
+
+// createFileUpload creates a new file upload request in Notion
+func (u *ImageUploader) createFileUpload(ctx context.Context, externalURL, filename string) (*FileUploadObject, error) {
+	reqBody := CreateFileUploadRequest{
+		Mode:        FileUploadModeExternalURL,
+		ExternalURL: externalURL,
+		Filename:    filename,
 	}
 
 	var fileUpload FileUploadObject
-	if err := json.NewDecoder(resp.Body).Decode(&fileUpload); err != nil {
+	if err := u.client.Raw().Do(ctx, "create file upload", http.MethodPost, "/file_uploads", reqBody, &fileUpload); err != nil {
 		return nil, err
 	}
 
@@ -97,28 +357,8 @@ func (u *ImageUploader) createFileUpload(ctx context.Context, externalURL, filen
 
 // retrieveFileUpload retrieves the current state of a file upload
 func (u *ImageUploader) retrieveFileUpload(ctx context.Context, fileUploadID string) (*FileUploadObject, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET",
-		fmt.Sprintf("https://api.notion.com/v1/file_uploads/%s", fileUploadID), nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+u.notionToken)
-	req.Header.Set("Notion-Version", "2022-06-28")
-
-	resp, err := u.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
-	}
-
 	var fileUpload FileUploadObject
-	if err := json.NewDecoder(resp.Body).Decode(&fileUpload); err != nil {
+	if err := u.client.Raw().Do(ctx, "retrieve file upload", http.MethodGet, "/file_uploads/"+fileUploadID, nil, &fileUpload); err != nil {
 		return nil, err
 	}
 
@@ -126,24 +366,16 @@ func (u *ImageUploader) retrieveFileUpload(ctx context.Context, fileUploadID str
 }
 
 // pollForCompletion polls the file upload status until it completes or times out
+// Waiting between polls is cancelled with ctx, so concurrent uploads never block shutdown
 func (u *ImageUploader) pollForCompletion(ctx context.Context, fileUploadID string) (string, error) {
-	deadline := time.Now().Add(u.timeout)
+	ctx, cancel := context.WithTimeout(ctx, u.timeout)
+	defer cancel()
 
 	for {
-		// Check if we've exceeded timeout
-		if time.Now().After(deadline) {
-			return "", fmt.Errorf("upload timed out after %v", u.timeout)
-		}
-
-		// Check context cancellation
-		if ctx.Err() != nil {
-			return "", ctx.Err()
-		}
-
 		// Retrieve FileUpload status
 		fileUpload, err := u.retrieveFileUpload(ctx, fileUploadID)
 		if err != nil {
-			return "", err
+			return "", u.pollError(ctx, err)
 		}
 
 		switch fileUpload.Status {
@@ -153,13 +385,30 @@ func (u *ImageUploader) pollForCompletion(ctx context.Context, fileUploadID stri
 			return "", fmt.Errorf("file upload failed")
 		case FileUploadStatusPending:
 			// Continue polling
-			time.Sleep(u.pollInterval)
+			if err := sleepContext(ctx, u.pollInterval); err != nil {
+				return "", u.pollError(ctx, err)
+			}
 		default:
 			return "", fmt.Errorf("unknown status: %s", fileUpload.Status)
 		}
 	}
 }
 
+// pollError reports the upload timeout instead of a bare context error when the deadline expired
+func (u *ImageUploader) pollError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("upload timed out after %v", u.timeout)
+	}
+	return err
+}
+
+const (
+	// maxFilenameLength caps upload filenames, well under Notion's 900 byte limit
+	maxFilenameLength = 100
+	// maxExtensionLength is the longest suffix still treated as a file extension
+	maxExtensionLength = 10
+)
+
 // extractFilenameFromURL extracts a filename from a URL
 func extractFilenameFromURL(imageURL string) string {
 	parsedURL, err := url.Parse(imageURL)
@@ -175,96 +424,50 @@ func extractFilenameFromURL(imageURL string) string {
 	}
 
 	// Ensure filename is not too long (max 900 bytes recommended by Notion)
-	if len(filename) > 100 {
+	if len(filename) > maxFilenameLength {
 		ext := path.Ext(filename)
-		filename = filename[:100-len(ext)] + ext
+		stem := strings.TrimSuffix(filename, ext)
+		if len(ext) > maxExtensionLength {
+			// Not a real extension; filenameForContentType replaces it when the type is known
+			ext = ".png"
+		}
+		if stem == "" {
+			stem = "image"
+		}
+		// Cutting at a byte offset can split a character, so drop any partial one
+		filename = strings.ToValidUTF8(stem[:min(len(stem), maxFilenameLength-len(ext))], "") + ext
 	}
 
 	return filename
 }
 
-// SetPageCover sets the cover of a Notion page using a FileUpload ID
-func SetPageCover(ctx context.Context, notionToken string, pageID string, fileUploadID string) error {
-	// Build raw JSON since library doesn't support file_upload type yet
-	updatePayload := map[string]interface{}{
-		"cover": map[string]interface{}{
-			"type": "file_upload",
-			"file_upload": map[string]string{
-				"id": fileUploadID,
-			},
-		},
-	}
-
-	bodyBytes, err := json.Marshal(updatePayload)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "PATCH",
-		fmt.Sprintf("https://api.notion.com/v1/pages/%s", pageID),
-		bytes.NewReader(bodyBytes))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+notionToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Notion-Version", "2022-06-28")
-
-	httpClient := &http.Client{Timeout: 10 * time.Second}
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
-	}
-
-	return nil
+// contentTypeExtensions maps upload content types to the file extension Notion expects
+var contentTypeExtensions = map[string]string{
+	"image/png":                ".png",
+	"image/jpeg":               ".jpg",
+	"image/gif":                ".gif",
+	"image/webp":               ".webp",
+	"image/svg+xml":            ".svg",
+	"image/bmp":                ".bmp",
+	"image/tiff":               ".tiff",
+	"image/avif":               ".avif",
+	"image/x-icon":             ".ico",
+	"image/vnd.microsoft.icon": ".ico",
 }
 
-// SetPageIcon sets the icon of a Notion page using a FileUpload ID
-func SetPageIcon(ctx context.Context, notionToken string, pageID string, fileUploadID string) error {
-	// Build raw JSON since library doesn't support file_upload type yet
-	updatePayload := map[string]interface{}{
-		"icon": map[string]interface{}{
-			"type": "file_upload",
-			"file_upload": map[string]string{
-				"id": fileUploadID,
-			},
-		},
-	}
-
-	bodyBytes, err := json.Marshal(updatePayload)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequestWithContext(ctx, "PATCH",
-		fmt.Sprintf("https://api.notion.com/v1/pages/%s", pageID),
-		bytes.NewReader(bodyBytes))
-	if err != nil {
-		return err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+notionToken)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Notion-Version", "2022-06-28")
+// filenameForContentType derives a filename from the URL whose extension matches contentType
+// Notion rejects uploads whose extension disagrees with the declared content type
+func filenameForContentType(imageURL, contentType string) string {
+	filename := extractFilenameFromURL(imageURL)
 
-	httpClient := &http.Client{Timeout: 10 * time.Second}
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		return err
+	ext, ok := contentTypeExtensions[contentType]
+	if !ok {
+		return filename
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+	current := strings.ToLower(path.Ext(filename))
+	if current == ext || (ext == ".jpg" && current == ".jpeg") {
+		return filename
 	}
-
-	return nil
+	return strings.TrimSuffix(filename, path.Ext(filename)) + ext
 }
@@ -0,0 +1,53 @@
+package overwrite
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Policy decides whether a field that may already hold a value is written
+type Policy string
+
+const (
+	// Always replaces the current value
+	Always Policy = "always"
+	// IfEmpty only fills the field when it has no value
+	IfEmpty Policy = "if-empty"
+	// Never leaves the field untouched
+	Never Policy = "never"
+)
+
+// Parse parses a policy name
+func Parse(value string) (Policy, error) {
+	switch policy := Policy(strings.ToLower(strings.TrimSpace(value))); policy {
+	case Always, IfEmpty, Never:
+		return policy, nil
+	case "if_empty", "ifempty":
+		return IfEmpty, nil
+	default:
+		return "", fmt.Errorf("unknown overwrite policy %q (expected always, if-empty or never)", value)
+	}
+}
+
+// Allows reports whether the field may be written given whether it currently has a value
+func (p Policy) Allows(hasValue bool) bool {
+	switch p {
+	case Always:
+		return true
+	case IfEmpty:
+		return !hasValue
+	default:
+		return false
+	}
+}
+
+// Fields holds the policy of every field the processor writes
+type Fields struct {
+	Cover         Policy
+	Icon          Policy
+	Author        Policy
+	Image         Policy
+	Title         Policy
+	Summary       Policy
+	DatePublished Policy
+}
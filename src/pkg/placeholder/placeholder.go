@@ -0,0 +1,226 @@
+package placeholder
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"net/url"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// ContentType is the media type of generated covers
+	ContentType = "image/png"
+
+	// Width and Height match Notion's recommended cover size
+	Width  = 1500
+	Height = 600
+
+	titleSize  = 72
+	domainSize = 36
+	margin     = 120
+	maxLines   = 3
+)
+
+// Cover is a generated cover image
+type Cover struct {
+	Filename string
+	Data     []byte
+	Color    color.RGBA
+}
+
+// Render draws a cover showing the title and domain on a background color derived from the domain
+func Render(title, pageURL string) (*Cover, error) {
+	domain := Domain(pageURL)
+	if strings.TrimSpace(title) == "" {
+		title = domain
+	}
+
+	titleFace, err := newFace(gobold.TTF, titleSize)
+	if err != nil {
+		return nil, err
+	}
+	defer titleFace.Close()
+
+	domainFace, err := newFace(goregular.TTF, domainSize)
+	if err != nil {
+		return nil, err
+	}
+	defer domainFace.Close()
+
+	background := Color(domain)
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	// Title lines are vertically centered above the domain line
+	lines := wrap(titleFace, title, Width-2*margin, maxLines)
+	lineHeight := titleFace.Metrics().Height.Ceil()
+	top := (Height-lineHeight*len(lines)-domainSize*2)/2 + titleFace.Metrics().Ascent.Ceil()
+	for i, line := range lines {
+		drawText(img, titleFace, line, margin, top+i*lineHeight, color.White)
+	}
+
+	domainColor := color.NRGBA{R: 255, G: 255, B: 255, A: 190}
+	drawText(img, domainFace, domain, margin, top+len(lines)*lineHeight+domainSize, domainColor)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode cover: %w", err)
+	}
+
+	return &Cover{
+		Filename: filename(domain),
+		Data:     buf.Bytes(),
+		Color:    background,
+	}, nil
+}
+
+// Domain returns the host of a URL without a leading "www."
+func Domain(pageURL string) string {
+	parsed, err := url.Parse(pageURL)
+	if err != nil || parsed.Hostname() == "" {
+		return pageURL
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// Color derives a stable background color from a domain: the hash picks the hue,
+// saturation and lightness are fixed so white text stays readable
+func Color(domain string) color.RGBA {
+	h := fnv.New32a()
+	h.Write([]byte(domain))
+	hue := float64(h.Sum32()%360) / 360
+	return hslToRGB(hue, 0.55, 0.38)
+}
+
+// newFace loads a TrueType font at the given size
+func newFace(ttf []byte, size float64) (font.Face, error) {
+	parsed, err := opentype.Parse(ttf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font: %w", err)
+	}
+	face, err := opentype.NewFace(parsed, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load font: %w", err)
+	}
+	return face, nil
+}
+
+// wrap breaks text into at most maxLines lines no wider than maxWidth, ending with "…" when cut
+func wrap(face font.Face, text string, maxWidth, maxLines int) []string {
+	limit := fixed.I(maxWidth)
+	var lines []string
+	var current string
+
+	words := strings.Fields(text)
+	for i, word := range words {
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if font.MeasureString(face, candidate) <= limit {
+			current = candidate
+			continue
+		}
+
+		if current != "" {
+			lines = append(lines, current)
+		}
+		current = word
+		if len(lines) == maxLines {
+			current = ""
+			lines[maxLines-1] = ellipsize(face, lines[maxLines-1]+" "+strings.Join(words[i:], " "), limit)
+			return lines
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+
+	// Single words wider than the cover are cut as well
+	for i, line := range lines {
+		if font.MeasureString(face, line) > limit {
+			lines[i] = ellipsize(face, line, limit)
+		}
+	}
+	return lines
+}
+
+// ellipsize shortens text until it fits within limit with a trailing ellipsis
+func ellipsize(face font.Face, text string, limit fixed.Int26_6) string {
+	runes := []rune(text)
+	for len(runes) > 0 {
+		candidate := strings.TrimRight(string(runes), " ") + "…"
+		if font.MeasureString(face, candidate) <= limit {
+			return candidate
+		}
+		runes = runes[:len(runes)-1]
+	}
+	return "…"
+}
+
+// drawText draws a single line with its baseline at y
+func drawText(img draw.Image, face font.Face, text string, x, y int, c color.Color) {
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	drawer.DrawString(text)
+}
+
+// filename builds the upload filename for a domain's cover
+func filename(domain string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return '-'
+	}, domain)
+	if name == "" {
+		name = "bookmark"
+	}
+	return "cover-" + name + ".png"
+}
+
+// hslToRGB converts a color from HSL (all components 0..1) to RGB
+func hslToRGB(h, s, l float64) color.RGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h*6, 2)-1))
+	m := l - c/2
+
+	var r, g, b float64
+	switch int(h * 6) {
+	case 0:
+		r, g, b = c, x, 0
+	case 1:
+		r, g, b = x, c, 0
+	case 2:
+		r, g, b = 0, c, x
+	case 3:
+		r, g, b = 0, x, c
+	case 4:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	return color.RGBA{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+		A: 255,
+	}
+}
@@ -0,0 +1,68 @@
+package platforms
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+)
+
+// githubRepoURL is the REST API endpoint for a repository
+const githubRepoURL = "https://api.github.com/repos/"
+
+// githubReserved are top-level github.com paths that are not user or organization names
+var githubReserved = map[string]bool{
+	"about": true, "apps": true, "blog": true, "collections": true, "customer-stories": true,
+	"enterprise": true, "events": true, "explore": true, "features": true, "issues": true,
+	"login": true, "marketplace": true, "new": true, "notifications": true, "orgs": true,
+	"pricing": true, "pulls": true, "search": true, "security": true, "settings": true,
+	"sponsors": true, "topics": true, "trending": true,
+}
+
+// isGitHubRepo matches github.com/<owner>/<repo> and any page below it
+func isGitHubRepo(u *url.URL) bool {
+	if strings.ToLower(strings.TrimPrefix(u.Hostname(), "www.")) != "github.com" {
+		return false
+	}
+	segments := pathSegments(u)
+	return len(segments) >= 2 && !githubReserved[strings.ToLower(segments[0])]
+}
+
+// extractGitHub reads stars, forks, language, topics and license from the GitHub API
+func (e *Extractor) extractGitHub(ctx context.Context, u *url.URL, content *scraper.ScrapedContent, info *Info) error {
+	segments := pathSegments(u)
+	repo := segments[0] + "/" + strings.TrimSuffix(segments[1], ".git")
+
+	header := make(http.Header)
+	header.Set("Accept", "application/vnd.github+json")
+	header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if e.githubToken != "" {
+		header.Set("Authorization", "Bearer "+e.githubToken)
+	}
+
+	var resp struct {
+		FullName        string   `json:"full_name"`
+		StargazersCount int      `json:"stargazers_count"`
+		ForksCount      int      `json:"forks_count"`
+		Language        string   `json:"language"`
+		Topics          []string `json:"topics"`
+		License         *struct {
+			SPDXID string `json:"spdx_id"`
+		} `json:"license"`
+	}
+	if err := e.getJSON(ctx, githubRepoURL+repo, header, &resp); err != nil {
+		return err
+	}
+
+	info.set(FieldRepo, resp.FullName)
+	info.set(FieldStars, float64(resp.StargazersCount))
+	info.set(FieldForks, float64(resp.ForksCount))
+	info.set(FieldLanguage, resp.Language)
+	info.set(FieldTopics, resp.Topics)
+	if resp.License != nil && resp.License.SPDXID != "NOASSERTION" {
+		info.set(FieldLicense, resp.License.SPDXID)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package platforms
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/textutil"
+)
+
+const (
+	// arxivQueryURL is the arXiv export API, which returns an Atom feed
+	arxivQueryURL = "https://export.arxiv.org/api/query?id_list="
+	// doiURL resolves a DOI; with a CSL JSON Accept header it returns citation metadata
+	doiURL = "https://doi.org/"
+	// acceptCSLJSON requests citation metadata through DOI content negotiation
+	acceptCSLJSON = "application/vnd.citationstyles.csl+json"
+)
+
+var (
+	// New-style (2301.01234) and old-style (hep-th/9901001) identifiers, with an optional version
+	arxivIDPattern = regexp.MustCompile(`^([0-9]{4}\.[0-9]{4,5}|[a-z-]+(?:\.[A-Z]{2})?/[0-9]{7})(v[0-9]+)?$`)
+	doiPattern     = regexp.MustCompile(`\b(10\.[0-9]{4,9}/[^\s?#]+)`)
+)
+
+// isArXiv matches arxiv.org abstract, PDF and HTML pages
+func isArXiv(u *url.URL) bool {
+	return hostIs(u, "arxiv.org") && arxivID(u) != ""
+}
+
+// arxivID returns the unversioned identifier of an arXiv page
+func arxivID(u *url.URL) string {
+	segments := pathSegments(u)
+	if len(segments) < 2 {
+		return ""
+	}
+	switch segments[0] {
+	case "abs", "pdf", "html":
+	default:
+		return ""
+	}
+	id := strings.TrimSuffix(strings.Join(segments[1:], "/"), ".pdf")
+	match := arxivIDPattern.FindStringSubmatch(id)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// arxivFeed is the part of the arXiv Atom response that is used
+type arxivFeed struct {
+	Entries []struct {
+		Published string `xml:"published"`
+		Authors   []struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		JournalRef      string `xml:"http://arxiv.org/schemas/atom journal_ref"`
+		DOI             string `xml:"http://arxiv.org/schemas/atom doi"`
+		PrimaryCategory struct {
+			Term string `xml:"term,attr"`
+		} `xml:"http://arxiv.org/schemas/atom primary_category"`
+	} `xml:"entry"`
+}
+
+// extractArXiv reads authors, venue, publication date and category from the arXiv API
+func (e *Extractor) extractArXiv(ctx context.Context, u *url.URL, content *scraper.ScrapedContent, info *Info) error {
+	id := arxivID(u)
+	info.set(FieldArXivID, id)
+
+	header := make(http.Header)
+	header.Set("Accept", "application/atom+xml")
+	body, err := e.get(ctx, arxivQueryURL+url.QueryEscape(id), header)
+	if err != nil {
+		return err
+	}
+
+	var feed arxivFeed
+	if err := xml.Unmarshal(body, &feed); err != nil {
+		return fmt.Errorf("failed to parse arXiv response: %w", err)
+	}
+	if len(feed.Entries) == 0 {
+		return fmt.Errorf("paper %s not found", id)
+	}
+	entry := feed.Entries[0]
+
+	authors := make([]string, 0, len(entry.Authors))
+	for _, author := range entry.Authors {
+		if name := strings.TrimSpace(author.Name); name != "" {
+			authors = append(authors, name)
+		}
+	}
+	info.set(FieldAuthors, authors)
+	info.set(FieldVenue, textutil.FirstNonEmpty(textutil.CollapseSpace(entry.JournalRef), "arXiv"))
+	info.set(FieldCategory, entry.PrimaryCategory.Term)
+	info.set(FieldDOI, entry.DOI)
+	if published, err := time.Parse(time.RFC3339, strings.TrimSpace(entry.Published)); err == nil {
+		info.set(FieldPublished, published)
+	}
+	return nil
+}
+
+// hasDOI matches doi.org links and publisher pages whose path contains a DOI
+func hasDOI(u *url.URL) bool {
+	return pageDOI(u) != ""
+}
+
+// pageDOI returns the DOI in a URL's path
+func pageDOI(u *url.URL) string {
+	path, err := url.PathUnescape(u.EscapedPath())
+	if err != nil {
+		path = u.Path
+	}
+	if hostIs(u, "doi.org") {
+		return strings.TrimPrefix(path, "/")
+	}
+	match := doiPattern.FindStringSubmatch(path)
+	if match == nil {
+		return ""
+	}
+	// Publisher paths often append a page type after the DOI (/full, /pdf, /abstract)
+	doi := match[1]
+	for _, suffix := range []string{"/full", "/pdf", "/abstract", "/epdf"} {
+		doi = strings.TrimSuffix(doi, suffix)
+	}
+	return strings.TrimSuffix(doi, ".pdf")
+}
+
+// cslItem is the part of a CSL JSON citation that is used
+type cslItem struct {
+	Author []struct {
+		Given   string `json:"given"`
+		Family  string `json:"family"`
+		Literal string `json:"literal"`
+	} `json:"author"`
+	ContainerTitle interface{} `json:"container-title"`
+	Publisher      string      `json:"publisher"`
+	Issued         struct {
+		DateParts [][]int `json:"date-parts"`
+	} `json:"issued"`
+}
+
+// extractDOI reads authors, venue and publication date through DOI content negotiation
+func (e *Extractor) extractDOI(ctx context.Context, u *url.URL, content *scraper.ScrapedContent, info *Info) error {
+	doi := pageDOI(u)
+	info.set(FieldDOI, doi)
+
+	header := make(http.Header)
+	header.Set("Accept", acceptCSLJSON)
+	var item cslItem
+	if err := e.getJSON(ctx, doiURL+doi, header, &item); err != nil {
+		return err
+	}
+
+	authors := make([]string, 0, len(item.Author))
+	for _, author := range item.Author {
+		name := textutil.FirstNonEmpty(author.Literal, strings.TrimSpace(author.Given+" "+author.Family))
+		if name != "" {
+			authors = append(authors, name)
+		}
+	}
+	info.set(FieldAuthors, authors)
+
+	// container-title is a string in most responses but a list in some
+	switch title := item.ContainerTitle.(type) {
+	case string:
+		info.set(FieldVenue, title)
+	case []interface{}:
+		if len(title) > 0 {
+			info.set(FieldVenue, fmt.Sprint(title[0]))
+		}
+	}
+	if _, ok := info.Fields[FieldVenue]; !ok {
+		info.set(FieldVenue, item.Publisher)
+	}
+	info.set(FieldPublisher, item.Publisher)
+
+	if len(item.Issued.DateParts) > 0 {
+		if published, ok := issuedDate(item.Issued.DateParts[0]); ok {
+			info.set(FieldPublished, published)
+		}
+	}
+	return nil
+}
+
+// issuedDate converts CSL date parts ([year, month, day], month and day optional)
+func issuedDate(parts []int) (time.Time, bool) {
+	if len(parts) == 0 || parts[0] == 0 {
+		return time.Time{}, false
+	}
+	month, day := 1, 1
+	if len(parts) > 1 && parts[1] > 0 {
+		month = parts[1]
+	}
+	if len(parts) > 2 && parts[2] > 0 {
+		day = parts[2]
+	}
+	return time.Date(parts[0], time.Month(month), day, 0, 0, 0, 0, time.UTC), true
+}
@@ -0,0 +1,273 @@
+package platforms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/httpfetch"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+)
+
+// Platform identifies a site with platform-specific metadata
+type Platform string
+
+const (
+	PlatformReddit     Platform = "reddit"
+	PlatformYouTube    Platform = "youtube"
+	PlatformGitHub     Platform = "github"
+	PlatformArXiv      Platform = "arxiv"
+	PlatformDOI        Platform = "doi"
+	PlatformHackerNews Platform = "hackernews"
+)
+
+// Field names produced by the extractors, used as keys in Info.Fields and in property mappings
+const (
+	FieldPlatform        = "platform"         // text: the detected platform
+	FieldPublisher       = "publisher"        // text: site or publisher name from the scraper
+	FieldLang            = "lang"             // text: page language from the scraper
+	FieldAuthor          = "author"           // text: Reddit or Hacker News poster
+	FieldSubreddit       = "subreddit"        // text: Reddit community, without "r/"
+	FieldScore           = "score"            // number: Reddit upvotes or Hacker News points
+	FieldComments        = "comments"         // number: Hacker News comment count
+	FieldChannel         = "channel"          // text: YouTube channel name
+	FieldChannelURL      = "channel_url"      // url: YouTube channel page
+	FieldDuration        = "duration"         // text: YouTube video length ("1:02:03")
+	FieldDurationSeconds = "duration_seconds" // number: YouTube video length in seconds
+	FieldRepo            = "repo"             // text: GitHub "owner/name"
+	FieldStars           = "stars"            // number: GitHub stargazers
+	FieldForks           = "forks"            // number: GitHub forks
+	FieldLanguage        = "language"         // text: GitHub primary language
+	FieldTopics          = "topics"           // list: GitHub topics
+	FieldLicense         = "license"          // text: GitHub license SPDX identifier
+	FieldArXivID         = "arxiv_id"         // text: arXiv identifier
+	FieldDOI             = "doi"              // text: DOI of a paper
+	FieldAuthors         = "authors"          // list: paper authors
+	FieldVenue           = "venue"            // text: journal, conference or "arXiv"
+	FieldPublished       = "published"        // date: paper publication date
+	FieldCategory        = "category"         // text: arXiv primary category
+	FieldLinkedURL       = "linked_url"       // url: the story a Hacker News item links to
+)
+
+// knownFields lists every field an extractor may produce
+var knownFields = map[string]bool{
+	FieldPlatform: true, FieldPublisher: true, FieldLang: true, FieldAuthor: true,
+	FieldSubreddit: true, FieldScore: true, FieldComments: true,
+	FieldChannel: true, FieldChannelURL: true, FieldDuration: true, FieldDurationSeconds: true,
+	FieldRepo: true, FieldStars: true, FieldForks: true, FieldLanguage: true, FieldTopics: true, FieldLicense: true,
+	FieldArXivID: true, FieldDOI: true, FieldAuthors: true, FieldVenue: true, FieldPublished: true, FieldCategory: true,
+	FieldLinkedURL: true,
+}
+
+// maxAPIBytes limits the size of platform API responses
+const maxAPIBytes = 2 * 1024 * 1024
+
+// Info is the platform metadata extracted for a bookmark
+// Field values are strings, float64 numbers, []string lists or time.Time dates
+type Info struct {
+	Platform Platform
+	Fields   map[string]interface{}
+}
+
+// set stores a field, ignoring empty values
+func (i *Info) set(field string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		if v = strings.TrimSpace(v); v == "" {
+			return
+		}
+		value = v
+	case []string:
+		if len(v) == 0 {
+			return
+		}
+	case nil:
+		return
+	}
+	i.Fields[field] = value
+}
+
+// Summary lists the extracted fields for log output ("stars=120, language=Go")
+func (i *Info) Summary() string {
+	keys := make([]string, 0, len(i.Fields))
+	for key := range i.Fields {
+		if key != FieldPlatform {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value := i.Fields[key]
+		if list, ok := value.([]string); ok {
+			value = strings.Join(list, ", ")
+		}
+		parts = append(parts, fmt.Sprintf("%s=%v", key, value))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Options holds the optional settings of an Extractor
+type Options struct {
+	// GitHubToken authenticates GitHub API calls, raising the rate limit (empty = anonymous)
+	GitHubToken string
+}
+
+// Extractor collects platform metadata from the scraped content and, for platforms whose
+// pages don't carry it, from their public APIs
+type Extractor struct {
+	fetcher     *httpfetch.Fetcher
+	githubToken string
+}
+
+// NewExtractor creates a platform extractor; API calls go through the fetcher
+func NewExtractor(fetcher *httpfetch.Fetcher, options Options) *Extractor {
+	return &Extractor{
+		fetcher:     fetcher,
+		githubToken: options.GitHubToken,
+	}
+}
+
+// platformExtractor fills in the fields of one platform for a matching URL
+type platformExtractor struct {
+	platform Platform
+	detect   func(u *url.URL) bool
+	extract  func(e *Extractor, ctx context.Context, u *url.URL, content *scraper.ScrapedContent, info *Info) error
+}
+
+var extractors = []platformExtractor{
+	{PlatformReddit, isReddit, (*Extractor).extractReddit},
+	{PlatformYouTube, isYouTube, (*Extractor).extractYouTube},
+	{PlatformGitHub, isGitHubRepo, (*Extractor).extractGitHub},
+	{PlatformArXiv, isArXiv, (*Extractor).extractArXiv},
+	{PlatformHackerNews, isHackerNewsItem, (*Extractor).extractHackerNews},
+	// DOI last: publisher pages on any host can carry a DOI in their path
+	{PlatformDOI, hasDOI, (*Extractor).extractDOI},
+}
+
+// Extract returns the metadata of a bookmark: publisher and language for every page, plus
+// the platform fields when the URL belongs to a known platform. Fields gathered before an
+// API failure are kept, and the error is returned alongside them.
+func (e *Extractor) Extract(ctx context.Context, pageURL string, content *scraper.ScrapedContent) (*Info, error) {
+	info := &Info{Fields: make(map[string]interface{})}
+	if content != nil && content.Metadata != nil {
+		info.set(FieldPublisher, content.Metadata.Publisher)
+		if content.Metadata.Lang != nil {
+			info.set(FieldLang, *content.Metadata.Lang)
+		}
+	}
+
+	u, err := url.Parse(pageURL)
+	if err != nil || u.Host == "" {
+		return info, nil
+	}
+
+	for _, extractor := range extractors {
+		if !extractor.detect(u) {
+			continue
+		}
+		info.Platform = extractor.platform
+		info.set(FieldPlatform, string(extractor.platform))
+		if err := extractor.extract(e, ctx, u, content, info); err != nil {
+			return info, fmt.Errorf("%s: %w", extractor.platform, err)
+		}
+		break
+	}
+	return info, nil
+}
+
+// getJSON fetches a JSON document and decodes it into out
+func (e *Extractor) getJSON(ctx context.Context, rawURL string, header http.Header, out interface{}) error {
+	if header == nil {
+		header = make(http.Header)
+	}
+	if header.Get("Accept") == "" {
+		header.Set("Accept", httpfetch.AcceptJSON)
+	}
+
+	body, err := e.get(ctx, rawURL, header)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", rawURL, err)
+	}
+	return nil
+}
+
+// get fetches a document, limited to maxAPIBytes whether or not the response declares its length
+func (e *Extractor) get(ctx context.Context, rawURL string, header http.Header) ([]byte, error) {
+	resp, err := e.fetcher.GetWithHeader(ctx, rawURL, header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.ContentLength > maxAPIBytes {
+		return nil, fmt.Errorf("response is %d bytes, limit is %d", resp.ContentLength, maxAPIBytes)
+	}
+	// Read one byte past the limit to detect oversized chunked responses
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAPIBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(body) > maxAPIBytes {
+		return nil, fmt.Errorf("response exceeds the %d byte limit", maxAPIBytes)
+	}
+	return body, nil
+}
+
+// Mapping writes an extracted field to a bookmark property
+type Mapping struct {
+	Field    string
+	Property string
+}
+
+// ParseMappings parses "field=Property" pairs separated by commas
+func ParseMappings(value string) ([]Mapping, error) {
+	var mappings []Mapping
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		field, property, ok := strings.Cut(pair, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		property = strings.TrimSpace(property)
+		if !ok || field == "" || property == "" {
+			return nil, fmt.Errorf("invalid mapping %q (expected field=Property)", strings.TrimSpace(pair))
+		}
+		if !knownFields[field] {
+			return nil, fmt.Errorf("unknown platform field %q", field)
+		}
+		mappings = append(mappings, Mapping{Field: field, Property: property})
+	}
+	return mappings, nil
+}
+
+// hostIs reports whether a URL's host is one of the domains or a subdomain of one
+func hostIs(u *url.URL, domains ...string) bool {
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathSegments splits a URL path into its non-empty segments
+func pathSegments(u *url.URL) []string {
+	var segments []string
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
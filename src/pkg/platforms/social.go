@@ -0,0 +1,78 @@
+package platforms
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+)
+
+// hackerNewsItemURL is the Firebase API endpoint for a Hacker News item
+const hackerNewsItemURL = "https://hacker-news.firebaseio.com/v0/item/%d.json"
+
+// isReddit matches reddit.com (including old. and new. subdomains) and redd.it short links
+func isReddit(u *url.URL) bool {
+	return hostIs(u, "reddit.com", "redd.it")
+}
+
+// extractReddit reads the Reddit fields webmeatscraper already returns, with the
+// subreddit taken from the /r/<name>/ path when the scraper has none
+func (e *Extractor) extractReddit(ctx context.Context, u *url.URL, content *scraper.ScrapedContent, info *Info) error {
+	if content != nil && content.Metadata != nil {
+		meta := content.Metadata
+		for _, subreddit := range []*string{meta.RedditSubreddit, meta.Subreddit} {
+			if subreddit != nil && *subreddit != "" {
+				info.set(FieldSubreddit, strings.TrimPrefix(strings.TrimPrefix(*subreddit, "/"), "r/"))
+				break
+			}
+		}
+		if meta.RedditUpvotes != nil {
+			info.set(FieldScore, float64(*meta.RedditUpvotes))
+		}
+		info.set(FieldAuthor, strings.TrimPrefix(meta.RedditAuthor, "u/"))
+	}
+
+	if _, ok := info.Fields[FieldSubreddit]; !ok {
+		segments := pathSegments(u)
+		if len(segments) >= 2 && segments[0] == "r" {
+			info.set(FieldSubreddit, segments[1])
+		}
+	}
+	return nil
+}
+
+// isHackerNewsItem matches news.ycombinator.com/item?id=<n>
+func isHackerNewsItem(u *url.URL) bool {
+	return hostIs(u, "news.ycombinator.com") && u.Path == "/item" && u.Query().Get("id") != ""
+}
+
+// extractHackerNews reads the poster, points, comment count and linked story from the HN API
+func (e *Extractor) extractHackerNews(ctx context.Context, u *url.URL, content *scraper.ScrapedContent, info *Info) error {
+	id, err := strconv.Atoi(u.Query().Get("id"))
+	if err != nil {
+		return fmt.Errorf("invalid item id %q", u.Query().Get("id"))
+	}
+
+	var item struct {
+		By          string `json:"by"`
+		Score       int    `json:"score"`
+		Descendants int    `json:"descendants"`
+		URL         string `json:"url"`
+		Deleted     bool   `json:"deleted"`
+	}
+	if err := e.getJSON(ctx, fmt.Sprintf(hackerNewsItemURL, id), nil, &item); err != nil {
+		return err
+	}
+	if item.Deleted {
+		return nil
+	}
+
+	info.set(FieldAuthor, item.By)
+	info.set(FieldScore, float64(item.Score))
+	info.set(FieldComments, float64(item.Descendants))
+	info.set(FieldLinkedURL, item.URL)
+	return nil
+}
@@ -0,0 +1,116 @@
+package platforms
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/httpfetch"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+)
+
+// youtubeOEmbedURL returns the channel of a video without an API key
+const youtubeOEmbedURL = "https://www.youtube.com/oembed?format=json&url="
+
+var (
+	youtubeIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	// The watch page carries the length both in microdata and in the player config
+	youtubeDurationPattern = regexp.MustCompile(`itemprop="duration" content="(PT[0-9HMS.]+)"`)
+	youtubeLengthPattern   = regexp.MustCompile(`"lengthSeconds":"([0-9]+)"`)
+	isoDurationPattern     = regexp.MustCompile(`^PT(?:([0-9]+)H)?(?:([0-9]+)M)?(?:([0-9.]+)S)?$`)
+)
+
+// isYouTube matches links to a single YouTube video
+func isYouTube(u *url.URL) bool {
+	return youtubeID(u) != ""
+}
+
+// youtubeID returns the video ID of watch, shorts, live, embed and youtu.be links
+func youtubeID(u *url.URL) string {
+	var id string
+	switch {
+	case hostIs(u, "youtu.be"):
+		if segments := pathSegments(u); len(segments) > 0 {
+			id = segments[0]
+		}
+	case hostIs(u, "youtube.com", "youtube-nocookie.com"):
+		segments := pathSegments(u)
+		switch {
+		case len(segments) == 1 && segments[0] == "watch":
+			id = u.Query().Get("v")
+		case len(segments) >= 2 && (segments[0] == "shorts" || segments[0] == "live" || segments[0] == "embed"):
+			id = segments[1]
+		}
+	}
+	if !youtubeIDPattern.MatchString(id) {
+		return ""
+	}
+	return id
+}
+
+// extractYouTube reads the channel from oEmbed and the duration from the watch page
+func (e *Extractor) extractYouTube(ctx context.Context, u *url.URL, content *scraper.ScrapedContent, info *Info) error {
+	watchURL := "https://www.youtube.com/watch?v=" + youtubeID(u)
+
+	var oembed struct {
+		AuthorName string `json:"author_name"`
+		AuthorURL  string `json:"author_url"`
+	}
+	if err := e.getJSON(ctx, youtubeOEmbedURL+url.QueryEscape(watchURL), nil, &oembed); err != nil {
+		return fmt.Errorf("oEmbed: %w", err)
+	}
+	info.set(FieldChannel, oembed.AuthorName)
+	info.set(FieldChannelURL, oembed.AuthorURL)
+
+	header := make(http.Header)
+	header.Set("Accept", httpfetch.AcceptHTML)
+	page, err := e.get(ctx, watchURL, header)
+	if err != nil {
+		return fmt.Errorf("watch page: %w", err)
+	}
+	if seconds, ok := youtubeDuration(page); ok {
+		info.set(FieldDurationSeconds, float64(seconds))
+		info.set(FieldDuration, formatDuration(seconds))
+	}
+	return nil
+}
+
+// youtubeDuration finds the video length in a watch page
+func youtubeDuration(page []byte) (int, bool) {
+	if match := youtubeLengthPattern.FindSubmatch(page); match != nil {
+		if seconds, err := strconv.Atoi(string(match[1])); err == nil && seconds > 0 {
+			return seconds, true
+		}
+	}
+	if match := youtubeDurationPattern.FindSubmatch(page); match != nil {
+		return parseISODuration(string(match[1]))
+	}
+	return 0, false
+}
+
+// parseISODuration parses an ISO 8601 time duration such as "PT1H2M3S"
+func parseISODuration(value string) (int, bool) {
+	match := isoDurationPattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, false
+	}
+	hours, _ := strconv.Atoi(match[1])
+	minutes, _ := strconv.Atoi(match[2])
+	seconds, _ := strconv.ParseFloat(match[3], 64)
+	total := hours*3600 + minutes*60 + int(seconds)
+	return total, total > 0
+}
+
+// formatDuration renders seconds as "m:ss" or "h:mm:ss"
+func formatDuration(seconds int) string {
+	d := time.Duration(seconds) * time.Second
+	h, m, s := int(d.Hours()), int(d.Minutes())%60, seconds%60
+	if h > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%d:%02d", m, s)
+}
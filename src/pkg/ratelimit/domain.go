@@ -0,0 +1,103 @@
+package ratelimit
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// DomainLimiter keeps requests to the same site polite: at most a fixed number run at
+// once, and consecutive requests start at least a fixed delay apart
+type DomainLimiter struct {
+	maxConcurrent int
+	delay         time.Duration
+
+	mu      sync.Mutex
+	domains map[string]*domainState
+}
+
+// domainState tracks the requests to one site
+type domainState struct {
+	slots chan struct{}
+	// next is the earliest time the next request may start
+	next time.Time
+}
+
+// NewDomainLimiter creates a limiter allowing maxConcurrent requests per site (0 = 1)
+// started at least delay apart (0 = no delay)
+func NewDomainLimiter(maxConcurrent int, delay time.Duration) *DomainLimiter {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+	return &DomainLimiter{
+		maxConcurrent: maxConcurrent,
+		delay:         delay,
+		domains:       make(map[string]*domainState),
+	}
+}
+
+// Acquire waits until a request to the URL's site may start; the returned
+// release function must be called once the request is done
+func (l *DomainLimiter) Acquire(ctx context.Context, rawURL string) (release func(), err error) {
+	state := l.state(Domain(rawURL))
+
+	select {
+	case state.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release = func() { <-state.slots }
+
+	// Reserve a start time, so waiting requests are spaced out in arrival order
+	l.mu.Lock()
+	now := time.Now()
+	start := now
+	if state.next.After(now) {
+		start = state.next
+	}
+	state.next = start.Add(l.delay)
+	l.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+// state returns the tracking state of a site, creating it on first use
+func (l *DomainLimiter) state(domain string) *domainState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, ok := l.domains[domain]
+	if !ok {
+		state = &domainState{slots: make(chan struct{}, l.maxConcurrent)}
+		l.domains[domain] = state
+	}
+	return state
+}
+
+// Domain returns the registrable domain of a URL ("blog.example.co.uk" -> "example.co.uk"),
+// so subdomains of one site share a limit; unparsable URLs are their own domain
+func Domain(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return rawURL
+	}
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return domain
+	}
+	return host
+}
@@ -0,0 +1,199 @@
+package scrapecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// fileVersion is bumped when the entry format changes; older entries are treated as missing
+const fileVersion = 1
+
+// trackingParams are query parameters that don't change the page and are dropped from cache keys
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"igshid":  true,
+	"ref_src": true,
+}
+
+// Entry is a cached scrape result
+type Entry struct {
+	Version   int       `json:"version"`
+	URL       string    `json:"url"`
+	ScrapedAt time.Time `json:"scraped_at"`
+	RawJSON   string    `json:"raw_json"`
+}
+
+// Age returns how long ago the entry was scraped
+func (e Entry) Age() time.Duration {
+	return time.Since(e.ScrapedAt)
+}
+
+// Cache stores raw scraper responses on disk, one file per normalized URL
+type Cache struct {
+	dir string
+	ttl time.Duration
+}
+
+// New creates a cache in dir whose entries expire after ttl (0 = never)
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl}
+}
+
+// Dir returns the directory holding the cache entries
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Get returns the cached scrape of a URL unless it is missing, outdated or expired
+func (c *Cache) Get(rawURL string) (Entry, bool) {
+	normalized := NormalizeURL(rawURL)
+	entry, err := readEntry(c.path(normalized))
+	if err != nil || entry.URL != normalized || c.expired(entry) {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// Put stores the raw scraper response for a URL, replacing any previous entry
+func (c *Cache) Put(rawURL, rawJSON string) error {
+	normalized := NormalizeURL(rawURL)
+	data, err := json.Marshal(Entry{
+		Version:   fileVersion,
+		URL:       normalized,
+		ScrapedAt: time.Now(),
+		RawJSON:   rawJSON,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode scrape cache entry: %w", err)
+	}
+
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create scrape cache directory: %w", err)
+	}
+
+	// Write atomically so an interrupted run never leaves a truncated entry
+	path := c.path(normalized)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write scrape cache entry: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write scrape cache entry: %w", err)
+	}
+	return nil
+}
+
+// Prune deletes expired, outdated and unreadable entries (every entry if all is set)
+// and returns how many were removed and how many remain
+func (c *Cache) Prune(all bool) (removed, kept int, err error) {
+	files, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read scrape cache directory: %w", err)
+	}
+
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || (!strings.HasSuffix(name, ".json") && !strings.HasSuffix(name, ".tmp")) {
+			continue
+		}
+
+		path := filepath.Join(c.dir, name)
+		if !all && strings.HasSuffix(name, ".json") {
+			if entry, err := readEntry(path); err == nil && !c.expired(entry) {
+				kept++
+				continue
+			}
+		}
+
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, kept, fmt.Errorf("failed to remove %s: %w", name, err)
+		}
+		removed++
+	}
+	return removed, kept, nil
+}
+
+// expired reports whether an entry is older than the TTL
+func (c *Cache) expired(entry Entry) bool {
+	return c.ttl > 0 && entry.Age() > c.ttl
+}
+
+// path returns the entry file for a normalized URL
+func (c *Cache) path(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// readEntry loads an entry file, rejecting other format versions
+func readEntry(path string) (Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return Entry{}, err
+	}
+	if entry.Version != fileVersion {
+		return Entry{}, fmt.Errorf("unsupported scrape cache entry version %d", entry.Version)
+	}
+	return entry, nil
+}
+
+// NormalizeURL returns the cache key of a URL: lowercase scheme and host, no default
+// port, fragment or tracking parameters, and query parameters sorted by name
+func NormalizeURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return rawURL
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	port := parsed.Port()
+	if (parsed.Scheme == "http" && port == "80") || (parsed.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6 literal
+	}
+	parsed.Host = host
+	if port != "" {
+		parsed.Host = host + ":" + port
+	}
+
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	if parsed.Path == "" {
+		parsed.Path = "/"
+	}
+
+	query := parsed.Query()
+	for param := range query {
+		if trackingParams[strings.ToLower(param)] || strings.HasPrefix(strings.ToLower(param), "utm_") {
+			query.Del(param)
+		}
+	}
+	// Encode sorts parameters by key
+	parsed.RawQuery = query.Encode()
+
+	return parsed.String()
+}
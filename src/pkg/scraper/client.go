@@ -4,35 +4,111 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/httpfetch"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraperules"
 )
 
-// Client handles communication with the webmeatscraper service
+// healthCheckTimeout limits each /health request
+const healthCheckTimeout = 10 * time.Second
+
+// Client handles communication with the webmeatscraper service, spreading requests
+// across one or more instances
 type Client struct {
-	baseURL    string
+	endpoints  []*endpoint
 	httpClient *http.Client
+	retry      RetryPolicy
+	cooldown   time.Duration
+	rules      *scraperules.Rules
+
+	mu   sync.Mutex
+	next int // round-robin position
+}
+
+// ClientOptions holds the optional settings of a Client
+type ClientOptions struct {
+	// Timeout limits each scrape request (0 = 80s)
+	Timeout time.Duration
+	// Retry controls how transient failures are retried (zero value = single attempt)
+	Retry RetryPolicy
+	// Cooldown is how long an instance that failed is skipped before being tried again (0 = 30s)
+	Cooldown time.Duration
+	// Transport sends the HTTP requests (nil = http.DefaultTransport)
+	Transport http.RoundTripper
+	// Rules rewrite URLs or override the timeout per site (nil = none); the service has
+	// no way to send extra headers, so header rules must be rejected before they get here
+	Rules *scraperules.Rules
+}
+
+// StatusError is returned when the scraper service answers with a non-200 status
+type StatusError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is the delay requested by the service's Retry-After header (0 = none)
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("scraper returned status %d: %s", e.StatusCode, e.Body)
 }
 
 // NewClient creates a new scraper client
 func NewClient(baseURL string) *Client {
-	// Default to localhost if empty
-	if baseURL == "" {
-		baseURL = "http://localhost:7878"
-	}
+	return NewClientWithOptions([]string{baseURL}, ClientOptions{})
+}
 
-	// Ensure baseURL doesn't end with a slash
-	baseURL = strings.TrimRight(baseURL, "/")
+// NewClientWithOptions creates a scraper client for one or more scraper instances
+// with a custom timeout, retry policy and unhealthy cooldown
+func NewClientWithOptions(baseURLs []string, options ClientOptions) *Client {
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = 80 * time.Second // 80 second timeout for scraping requests
+	}
+	cooldown := options.Cooldown
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
 
-	return &Client{
-		baseURL: baseURL,
+	c := &Client{
 		httpClient: &http.Client{
-			Timeout: 80 * time.Second, // 80 second timeout for scraping requests
+			Timeout:   timeout,
+			Transport: options.Transport,
 		},
+		retry:    options.Retry,
+		cooldown: cooldown,
+		rules:    options.Rules,
+	}
+
+	for _, baseURL := range baseURLs {
+		// Ensure baseURL doesn't end with a slash
+		if baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/"); baseURL != "" {
+			c.endpoints = append(c.endpoints, &endpoint{baseURL: baseURL})
+		}
+	}
+	// Default to localhost if empty
+	if len(c.endpoints) == 0 {
+		c.endpoints = append(c.endpoints, &endpoint{baseURL: "http://localhost:7878"})
+	}
+
+	return c
+}
+
+// ParseBaseURLs splits a comma-separated list of scraper URLs from configuration
+func ParseBaseURLs(value string) []string {
+	var urls []string
+	for _, baseURL := range strings.Split(value, ",") {
+		if baseURL = strings.TrimSpace(baseURL); baseURL != "" {
+			urls = append(urls, baseURL)
+		}
 	}
+	return urls
 }
 
 // ScrapeResult contains both the parsed content and raw JSON response
@@ -41,24 +117,75 @@ type ScrapeResult struct {
 	RawJSON string
 }
 
-// Scrape sends a URL to the scraper service and returns the scraped content
+// Scrape sends a URL to the scraper service and returns the scraped content,
+// retrying transient failures according to the client's retry policy
 func (c *Client) Scrape(ctx context.Context, url string) (*ScrapeResult, error) {
 	if url == "" {
 		return nil, fmt.Errorf("URL is required")
 	}
 
-	// Create request body
-	reqBody := ScrapeRequest{
-		URL: url,
+	rule, target, err := applyRules(c.rules, url)
+	if err != nil {
+		return nil, err
+	}
+	reqBody := ScrapeRequest{URL: target}
+	httpClient := c.httpClient
+	if rule != nil && rule.Timeout > 0 {
+		client := *c.httpClient
+		client.Timeout = rule.Timeout
+		httpClient = &client
+	}
+
+	var result *ScrapeResult
+	err = c.retry.run(ctx, func() error {
+		var err error
+		result, err = c.scrapeOnce(ctx, httpClient, reqBody)
+		return err
+	})
+	return result, err
+}
+
+// scrapeOnce sends the URL to the next available instance, failing over to the
+// others when an instance is down or overloaded
+func (c *Client) scrapeOnce(ctx context.Context, httpClient *http.Client, reqBody ScrapeRequest) (*ScrapeResult, error) {
+	var lastErr error
+	for _, ep := range c.candidates() {
+		if ep.recovering() {
+			// The cooldown is over; make sure the instance is back before sending it work
+			if _, err := c.checkHealth(ctx, ep); err != nil {
+				lastErr = err
+				continue
+			}
+		}
+
+		result, err := c.scrapeAt(ctx, httpClient, ep.baseURL, reqBody)
+		if err == nil {
+			ep.markHealthy()
+			return result, nil
+		}
+		if ctx.Err() != nil || !endpointFailure(err) {
+			return nil, err
+		}
+
+		ep.markUnhealthy(err, c.cooldown)
+		lastErr = err
 	}
 
+	if len(c.endpoints) > 1 {
+		return nil, fmt.Errorf("all %d scraper instances failed, last error: %w", len(c.endpoints), lastErr)
+	}
+	return nil, lastErr
+}
+
+// scrapeAt makes a single scrape request to one instance
+func (c *Client) scrapeAt(ctx context.Context, httpClient *http.Client, baseURL string, reqBody ScrapeRequest) (*ScrapeResult, error) {
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/scrape", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/scrape", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -67,7 +194,7 @@ func (c *Client) Scrape(ctx context.Context, url string) (*ScrapeResult, error)
 	req.Header.Set("Accept", "application/json")
 
 	// Send request
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -81,25 +208,75 @@ func (c *Client) Scrape(ctx context.Context, url string) (*ScrapeResult, error)
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("scraper returned status %d: %s", resp.StatusCode, string(body))
+		return nil, &StatusError{
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			RetryAfter: httpfetch.ParseRetryAfter(resp.Header.Get("Retry-After")),
+		}
 	}
 
-	// Parse response
+	// Return both parsed content and raw JSON
+	return DecodeResult(string(body))
+}
+
+// DecodeResult parses a raw scraper response (e.g. one read back from a cache)
+func DecodeResult(rawJSON string) (*ScrapeResult, error) {
 	var content ScrapedContent
-	if err := json.Unmarshal(body, &content); err != nil {
+	if err := json.Unmarshal([]byte(rawJSON), &content); err != nil {
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	// Return both parsed content and raw JSON
 	return &ScrapeResult{
 		Content: &content,
-		RawJSON: string(body),
+		RawJSON: rawJSON,
 	}, nil
 }
 
-// Health checks if the scraper service is healthy and reachable
+// Health checks every scraper instance and succeeds if at least one is healthy
+// Instances that fail are skipped by Scrape until their cooldown is over
 func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/health", nil)
+	responses := make([]*HealthResponse, len(c.endpoints))
+	errs := make([]error, len(c.endpoints))
+
+	var wg sync.WaitGroup
+	for i, ep := range c.endpoints {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i], errs[i] = c.checkHealth(ctx, ep)
+		}()
+	}
+	wg.Wait()
+
+	for _, health := range responses {
+		if health != nil {
+			return health, nil
+		}
+	}
+	if len(c.endpoints) == 1 {
+		return nil, errs[0]
+	}
+	return nil, fmt.Errorf("no healthy scraper instance: %w", errors.Join(errs...))
+}
+
+// checkHealth queries an instance's /health endpoint and records the outcome
+func (c *Client) checkHealth(ctx context.Context, ep *endpoint) (*HealthResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	health, err := c.healthAt(ctx, ep.baseURL)
+	if err != nil {
+		err = fmt.Errorf("%s: %w", ep.baseURL, err)
+		ep.markUnhealthy(err, c.cooldown)
+		return nil, err
+	}
+	ep.markHealthy()
+	return health, nil
+}
+
+// healthAt checks if a scraper instance is healthy and reachable
+func (c *Client) healthAt(ctx context.Context, baseURL string) (*HealthResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/health", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create health check request: %w", err)
 	}
@@ -128,9 +305,23 @@ func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
 	return &health, nil
 }
 
-// Exit sends a GET request to the /exit endpoint to signal the scraper service to shut down
+// Exit signals every scraper instance to shut down
 func (c *Client) Exit(ctx context.Context) error {
-	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/exit", nil)
+	var errs []error
+	for _, ep := range c.endpoints {
+		if err := c.exitAt(ctx, ep.baseURL); err != nil {
+			if len(c.endpoints) > 1 {
+				err = fmt.Errorf("%s: %w", ep.baseURL, err)
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// exitAt sends a GET request to the /exit endpoint to signal a scraper instance to shut down
+func (c *Client) exitAt(ctx context.Context, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/exit", nil)
 	if err != nil {
 		return fmt.Errorf("failed to create exit request: %w", err)
 	}
@@ -148,7 +339,25 @@ func (c *Client) Exit(ctx context.Context) error {
 	return nil
 }
 
-// BaseURL returns the base URL of the scraper service
-func (c *Client) BaseURL() string {
-	return c.baseURL
+// BaseURLs returns the base URLs of the scraper instances
+func (c *Client) BaseURLs() []string {
+	urls := make([]string, len(c.endpoints))
+	for i, ep := range c.endpoints {
+		urls[i] = ep.baseURL
+	}
+	return urls
+}
+
+// Endpoints reports the health of each scraper instance
+func (c *Client) Endpoints() []EndpointStatus {
+	statuses := make([]EndpointStatus, len(c.endpoints))
+	for i, ep := range c.endpoints {
+		statuses[i] = ep.status()
+	}
+	return statuses
+}
+
+// Name describes the scraper in log output
+func (c *Client) Name() string {
+	return "webmeatscraper at " + strings.Join(c.BaseURLs(), ", ")
 }
@@ -0,0 +1,124 @@
+package scraper
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// endpoint is one scraper instance and its health
+type endpoint struct {
+	baseURL string
+
+	mu sync.Mutex
+	// unhealthy is set when the instance last failed; it is skipped until unhealthyUntil
+	unhealthy      bool
+	unhealthyUntil time.Time
+	lastErr        error
+}
+
+// EndpointStatus describes the health of a scraper instance
+type EndpointStatus struct {
+	BaseURL string
+	Healthy bool
+	// LastError is the failure that marked the instance unhealthy
+	LastError error
+	// RetryAt is when an unhealthy instance will be tried again
+	RetryAt time.Time
+}
+
+// markHealthy records a successful request
+func (e *endpoint) markHealthy() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.unhealthy = false
+	e.unhealthyUntil = time.Time{}
+	e.lastErr = nil
+}
+
+// markUnhealthy takes the instance out of rotation for the cooldown
+func (e *endpoint) markUnhealthy(err error, cooldown time.Duration) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.unhealthy = true
+	e.unhealthyUntil = time.Now().Add(cooldown)
+	e.lastErr = err
+}
+
+// coolingDown reports whether the instance is still being skipped
+func (e *endpoint) coolingDown(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.unhealthy && now.Before(e.unhealthyUntil)
+}
+
+// recovering reports whether the instance failed earlier and its cooldown is over
+func (e *endpoint) recovering() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.unhealthy && !time.Now().Before(e.unhealthyUntil)
+}
+
+// status returns a snapshot of the instance's health
+func (e *endpoint) status() EndpointStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return EndpointStatus{
+		BaseURL:   e.baseURL,
+		Healthy:   !e.unhealthy,
+		LastError: e.lastErr,
+		RetryAt:   e.unhealthyUntil,
+	}
+}
+
+// candidates returns the instances to try for the next request: the available ones in
+// round-robin order, or, when every instance is cooling down, all of them with the
+// soonest to recover first rather than failing without trying
+func (c *Client) candidates() []*endpoint {
+	c.mu.Lock()
+	start := c.next
+	c.next = (c.next + 1) % len(c.endpoints)
+	c.mu.Unlock()
+
+	now := time.Now()
+	var available, cooling []*endpoint
+	for i := range c.endpoints {
+		ep := c.endpoints[(start+i)%len(c.endpoints)]
+		if ep.coolingDown(now) {
+			cooling = append(cooling, ep)
+		} else {
+			available = append(available, ep)
+		}
+	}
+	if len(available) > 0 {
+		return available
+	}
+
+	sort.SliceStable(cooling, func(i, j int) bool {
+		return cooling[i].status().RetryAt.Before(cooling[j].status().RetryAt)
+	})
+	return cooling
+}
+
+// endpointFailure reports whether an error means the instance itself is unavailable
+// (unreachable, overloaded or behind a failing proxy), so the request should go elsewhere
+// Timeouts are left to the retry policy, since a slow page times out on every instance
+func endpointFailure(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) {
+		switch status.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return !netErr.Timeout()
+	}
+	return false
+}
@@ -0,0 +1,485 @@
+package scraper
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/textutil"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// nativePage is the JSON document produced by the built-in scraper: the fields the
+// webmeatscraper service returns plus the tags they were derived from
+type nativePage struct {
+	*ScrapedContent
+	Images    []string          `json:"images,omitempty"`
+	OpenGraph map[string]string `json:"openGraph,omitempty"`
+	Twitter   map[string]string `json:"twitter,omitempty"`
+	JSONLD    []interface{}     `json:"jsonLd,omitempty"`
+}
+
+// pageTags are the metadata tags found anywhere in a document
+type pageTags struct {
+	base          *url.URL
+	baseSet       bool
+	lang          string
+	title         string
+	meta          map[string]string
+	canonical     string
+	icons         map[string]string
+	ogImages      []string
+	twitterImages []string
+	linkImages    []string
+	jsonLD        []interface{}
+}
+
+// ldSecondaryTypes are JSON-LD types describing the site rather than the page itself
+var ldSecondaryTypes = map[string]bool{
+	"Organization":          true,
+	"NewsMediaOrganization": true,
+	"Corporation":           true,
+	"Person":                true,
+	"WebSite":               true,
+	"BreadcrumbList":        true,
+	"ListItem":              true,
+	"ImageObject":           true,
+	"SiteNavigationElement": true,
+	"SearchAction":          true,
+}
+
+// dateLayouts are the date formats accepted in meta tags and JSON-LD
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// extract builds the scraped content of a parsed document
+func extract(doc *html.Node, pageURL string) *nativePage {
+	base, _ := url.Parse(pageURL)
+	tags := &pageTags{
+		base:  base,
+		meta:  make(map[string]string),
+		icons: make(map[string]string),
+	}
+	tags.collect(doc)
+
+	objects := ldObjects(tags.jsonLD)
+	primary := ldPrimary(objects)
+
+	images := tags.images(primary)
+	metadata := &Metadata{
+		Title: textutil.FirstNonEmpty(
+			tags.meta["og:title"], tags.meta["twitter:title"],
+			ldText(primary["headline"]), ldText(primary["name"]), tags.title,
+		),
+		Description: textutil.FirstNonEmpty(
+			tags.meta["og:description"], tags.meta["twitter:description"],
+			tags.meta["description"], ldText(primary["description"]),
+		),
+		Author: textutil.FirstNonEmpty(
+			tags.meta["author"], nonURL(tags.meta["article:author"]),
+			strings.Join(ldNames(primary["author"]), ", "), tags.meta["twitter:creator"],
+		),
+		Publisher: textutil.FirstNonEmpty(
+			tags.meta["og:site_name"], ldText(primary["publisher"]),
+			ldPublisherName(objects), tags.meta["application-name"],
+		),
+		URL: textutil.FirstNonEmpty(
+			tags.resolve(tags.meta["og:url"]), tags.resolve(tags.canonical),
+			tags.resolve(ldURL(primary["url"])), pageURL,
+		),
+		DatePublished: parseDate(textutil.FirstNonEmpty(
+			tags.meta["article:published_time"], ldText(primary["datePublished"]),
+			tags.meta["datepublished"], tags.meta["date"], tags.meta["pubdate"], tags.meta["publish-date"],
+		)),
+		DateModified: parseDate(textutil.FirstNonEmpty(
+			tags.meta["article:modified_time"], tags.meta["og:updated_time"],
+			ldText(primary["dateModified"]), tags.meta["datemodified"],
+		)),
+	}
+
+	if metadata.DatePublished != nil {
+		metadata.Date = metadata.DatePublished
+	} else {
+		metadata.Date = metadata.DateModified
+	}
+	if lang := textutil.FirstNonEmpty(tags.lang, tags.meta["content-language"], strings.ReplaceAll(tags.meta["og:locale"], "_", "-")); lang != "" {
+		metadata.Lang = &lang
+	}
+	if logo := textutil.FirstNonEmpty(
+		tags.resolve(ldURL(ldField(primary["publisher"], "logo"))),
+		tags.resolve(tags.icons["apple-touch-icon"]), tags.resolve(tags.icons["icon"]),
+	); logo != "" {
+		metadata.Logo = &logo
+	}
+
+	content := &ScrapedContent{
+		Content:  extractText(doc, tags.base),
+		Metadata: metadata,
+	}
+	if len(images) > 0 {
+		content.Image = &images[0]
+		metadata.Image = &images[0]
+	}
+
+	return &nativePage{
+		ScrapedContent: content,
+		Images:         images,
+		OpenGraph:      tags.prefixed("og:", "article:"),
+		Twitter:        tags.prefixed("twitter:"),
+		JSONLD:         tags.jsonLD,
+	}
+}
+
+// collect records the metadata tags of a document
+func (t *pageTags) collect(n *html.Node) {
+	if n.Type == html.ElementNode {
+		switch n.DataAtom {
+		case atom.Svg:
+			// SVG has its own <title> elements
+			return
+		case atom.Html:
+			t.lang = getAttr(n, "lang")
+		case atom.Base:
+			if href := getAttr(n, "href"); href != "" && !t.baseSet && t.base != nil {
+				if resolved, err := t.base.Parse(href); err == nil {
+					t.base = resolved
+					t.baseSet = true
+				}
+			}
+		case atom.Title:
+			if t.title == "" {
+				t.title = textutil.CollapseSpace(textContent(n))
+			}
+		case atom.Meta:
+			t.addMeta(n)
+		case atom.Link:
+			t.addLink(n)
+		case atom.Script:
+			if strings.EqualFold(getAttr(n, "type"), "application/ld+json") {
+				var value interface{}
+				if err := json.Unmarshal([]byte(textContent(n)), &value); err == nil {
+					t.jsonLD = append(t.jsonLD, value)
+				}
+			}
+			return
+		}
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		t.collect(child)
+	}
+}
+
+// addMeta records a <meta> tag under its property, name or itemprop (first value wins)
+func (t *pageTags) addMeta(n *html.Node) {
+	key := strings.ToLower(textutil.FirstNonEmpty(getAttr(n, "property"), getAttr(n, "name"), getAttr(n, "itemprop"), getAttr(n, "http-equiv")))
+	value := strings.TrimSpace(getAttr(n, "content"))
+	if key == "" || value == "" {
+		return
+	}
+
+	switch key {
+	case "og:image", "og:image:url", "og:image:secure_url":
+		t.ogImages = append(t.ogImages, value)
+	case "twitter:image", "twitter:image:src":
+		t.twitterImages = append(t.twitterImages, value)
+	}
+	if _, ok := t.meta[key]; !ok {
+		t.meta[key] = value
+	}
+}
+
+// addLink records canonical, icon and image_src links
+func (t *pageTags) addLink(n *html.Node) {
+	href := getAttr(n, "href")
+	if href == "" {
+		return
+	}
+	for _, rel := range strings.Fields(strings.ToLower(getAttr(n, "rel"))) {
+		key := rel
+		switch rel {
+		case "canonical":
+			if t.canonical == "" {
+				t.canonical = href
+			}
+			continue
+		case "image_src":
+			t.linkImages = append(t.linkImages, href)
+			continue
+		case "apple-touch-icon", "apple-touch-icon-precomposed":
+			key = "apple-touch-icon"
+		case "icon":
+		default:
+			continue
+		}
+		if _, ok := t.icons[key]; !ok {
+			t.icons[key] = href
+		}
+	}
+}
+
+// images returns the page's image URLs in priority order: OpenGraph, Twitter Card, JSON-LD, image_src
+func (t *pageTags) images(primary map[string]interface{}) []string {
+	var raw []string
+	raw = append(raw, t.ogImages...)
+	raw = append(raw, t.twitterImages...)
+	raw = append(raw, ldURLs(primary["image"])...)
+	raw = append(raw, ldURLs(primary["thumbnailUrl"])...)
+	raw = append(raw, t.linkImages...)
+
+	seen := make(map[string]bool, len(raw))
+	var images []string
+	for _, value := range raw {
+		resolved := t.resolve(value)
+		if resolved == "" || seen[resolved] {
+			continue
+		}
+		seen[resolved] = true
+		images = append(images, resolved)
+	}
+	return images
+}
+
+// prefixed returns the meta tags starting with any of the prefixes, leaving out image
+// dimensions and other values that aren't URLs or text
+func (t *pageTags) prefixed(prefixes ...string) map[string]string {
+	result := make(map[string]string)
+	for key, value := range t.meta {
+		for _, prefix := range prefixes {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			if strings.Contains(key, "image:") && !strings.HasSuffix(key, ":url") && !strings.HasSuffix(key, ":secure_url") && !strings.HasSuffix(key, ":src") {
+				break
+			}
+			result[key] = value
+			break
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// resolve makes a URL absolute against the document base, returning "" for non-http(s) URLs
+func (t *pageTags) resolve(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" || t.base == nil {
+		return rawURL
+	}
+	resolved, err := t.base.Parse(rawURL)
+	if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+		return ""
+	}
+	return resolved.String()
+}
+
+// ldObjects flattens JSON-LD values (arrays and @graph lists) into their objects
+func ldObjects(values []interface{}) []map[string]interface{} {
+	var objects []map[string]interface{}
+	var visit func(value interface{})
+	visit = func(value interface{}) {
+		switch v := value.(type) {
+		case []interface{}:
+			for _, item := range v {
+				visit(item)
+			}
+		case map[string]interface{}:
+			if _, ok := v["@type"]; ok {
+				objects = append(objects, v)
+			}
+			if graph, ok := v["@graph"]; ok {
+				visit(graph)
+			}
+		}
+	}
+	for _, value := range values {
+		visit(value)
+	}
+	return objects
+}
+
+// ldPrimary returns the JSON-LD object describing the page (an article, video, product...)
+func ldPrimary(objects []map[string]interface{}) map[string]interface{} {
+	for _, object := range objects {
+		secondary := true
+		for _, typ := range ldTypes(object) {
+			if !ldSecondaryTypes[typ] {
+				secondary = false
+				break
+			}
+		}
+		if !secondary {
+			return object
+		}
+	}
+	return nil
+}
+
+// ldPublisherName returns the name of the first organization or website in the JSON-LD data
+func ldPublisherName(objects []map[string]interface{}) string {
+	for _, object := range objects {
+		for _, typ := range ldTypes(object) {
+			switch typ {
+			case "Organization", "NewsMediaOrganization", "Corporation", "WebSite":
+				if name := ldText(object["name"]); name != "" {
+					return name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// ldTypes returns the @type of a JSON-LD object, which may be a string or a list
+func ldTypes(object map[string]interface{}) []string {
+	switch v := object["@type"].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var types []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// ldText returns the text of a JSON-LD value: a string, an object's name or @value,
+// or the first such value of a list
+func ldText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]interface{}:
+		return textutil.FirstNonEmpty(ldText(v["name"]), ldText(v["@value"]))
+	case []interface{}:
+		for _, item := range v {
+			if text := ldText(item); text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
+
+// ldNames returns every name in a JSON-LD value such as a list of authors
+func ldNames(value interface{}) []string {
+	if list, ok := value.([]interface{}); ok {
+		var names []string
+		for _, item := range list {
+			names = append(names, ldNames(item)...)
+		}
+		return names
+	}
+	if name := nonURL(ldText(value)); name != "" {
+		return []string{name}
+	}
+	return nil
+}
+
+// ldURL returns the first URL of a JSON-LD value
+func ldURL(value interface{}) string {
+	if urls := ldURLs(value); len(urls) > 0 {
+		return urls[0]
+	}
+	return ""
+}
+
+// ldURLs returns the URLs of a JSON-LD value: strings, ImageObject-style objects or lists of either
+func ldURLs(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			return []string{v}
+		}
+	case map[string]interface{}:
+		if u := textutil.FirstNonEmpty(ldText(v["url"]), ldText(v["contentUrl"])); u != "" {
+			return []string{u}
+		}
+	case []interface{}:
+		var urls []string
+		for _, item := range v {
+			urls = append(urls, ldURLs(item)...)
+		}
+		return urls
+	}
+	return nil
+}
+
+// ldField returns a field of a JSON-LD object (or of the first object in a list)
+func ldField(value interface{}, key string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return v[key]
+	case []interface{}:
+		for _, item := range v {
+			if field := ldField(item, key); field != nil {
+				return field
+			}
+		}
+	}
+	return nil
+}
+
+// parseDate parses a date in any of the accepted layouts, returning nil when it can't
+func parseDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	for _, layout := range dateLayouts {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return &parsed
+		}
+	}
+	return nil
+}
+
+// nonURL drops values that are links (e.g. article:author pointing at a profile page)
+func nonURL(value string) string {
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		return ""
+	}
+	return value
+}
+
+// getAttr returns the trimmed value of an element attribute
+func getAttr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return strings.TrimSpace(a.Val)
+		}
+	}
+	return ""
+}
+
+// textContent concatenates the text nodes below n
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var visit func(*html.Node)
+	visit = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			b.WriteString(node.Data)
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(n)
+	return b.String()
+}
@@ -0,0 +1,164 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/httpfetch"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraperules"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/urlpolicy"
+)
+
+const (
+	defaultNativeTimeout  = 30 * time.Second
+	defaultNativeMaxBytes = 5 * 1024 * 1024
+)
+
+// NativeOptions holds the optional settings of the built-in scraper
+type NativeOptions struct {
+	// Timeout limits each page fetch (0 = 30s)
+	Timeout time.Duration
+	// MaxBytes limits the size of a fetched page (0 = 5MB)
+	MaxBytes int64
+	// Policy restricts which pages may be fetched (nil = no restrictions)
+	Policy *urlpolicy.Policy
+	// Retry controls how transient fetch failures are retried (zero value = single attempt)
+	Retry RetryPolicy
+	// Rules rewrite URLs, override the timeout or add headers per site (nil = none)
+	Rules *scraperules.Rules
+	// Transport sends the page requests, e.g. to record or replay them (nil = the policy's transport)
+	Transport http.RoundTripper
+}
+
+// Native is a scraper that fetches pages itself and extracts OpenGraph, Twitter Card,
+// JSON-LD and standard meta tags, so no external service is needed
+type Native struct {
+	fetcher *httpfetch.Fetcher
+	retry   RetryPolicy
+	rules   *scraperules.Rules
+}
+
+// NewNative creates a built-in scraper
+func NewNative(options NativeOptions) *Native {
+	timeout := options.Timeout
+	if timeout <= 0 {
+		timeout = defaultNativeTimeout
+	}
+	maxBytes := options.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultNativeMaxBytes
+	}
+
+	return &Native{
+		fetcher: httpfetch.NewWithOptions(httpfetch.Options{
+			Timeout:   timeout,
+			MaxBytes:  maxBytes,
+			Policy:    options.Policy,
+			Transport: options.Transport,
+		}),
+		retry: options.Retry,
+		rules: options.Rules,
+	}
+}
+
+// Scrape fetches a page and extracts its metadata and main text
+func (n *Native) Scrape(ctx context.Context, url string) (*ScrapeResult, error) {
+	if url == "" {
+		return nil, fmt.Errorf("URL is required")
+	}
+
+	rule, target, err := applyRules(n.rules, url)
+	if err != nil {
+		return nil, err
+	}
+	fetcher := n.fetcher
+	header := make(http.Header)
+	header.Set("Accept", httpfetch.AcceptHTML)
+	if rule != nil {
+		if rule.Timeout > 0 {
+			fetcher = n.fetcher.WithTimeout(rule.Timeout)
+		}
+		for name, value := range rule.Headers {
+			header.Set(name, value)
+		}
+	}
+
+	var pageURL, contentType string
+	var body []byte
+	err = n.retry.run(ctx, func() error {
+		var err error
+		pageURL, contentType, body, err = fetch(ctx, fetcher, target, header)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseHTML(pageURL, contentType, body)
+}
+
+// fetch downloads a page, returning the URL it ended up at after redirects
+func fetch(ctx context.Context, fetcher *httpfetch.Fetcher, url string, header http.Header) (string, string, []byte, error) {
+	resp, err := fetcher.GetWithHeader(ctx, url, header)
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to fetch page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil &&
+		mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return "", "", nil, fmt.Errorf("unsupported content type %s", mediaType)
+	}
+
+	body, err := fetcher.ReadBody(resp)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	// Redirects may have moved the page; relative URLs resolve against where it ended up
+	return resp.Request.URL.String(), contentType, body, nil
+}
+
+// Health always succeeds: the built-in scraper has no service to reach
+func (n *Native) Health(ctx context.Context) (*HealthResponse, error) {
+	return &HealthResponse{Status: "ok", Version: "native"}, nil
+}
+
+// Name describes the scraper in log output
+func (n *Native) Name() string {
+	return "built-in scraper"
+}
+
+// ParseHTML extracts content and metadata from an HTML document
+// contentType is the response header, used to decode non-UTF-8 pages
+func ParseHTML(pageURL, contentType string, body []byte) (*ScrapeResult, error) {
+	reader, err := charset.NewReader(bytes.NewReader(body), contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode page: %w", err)
+	}
+
+	doc, err := html.Parse(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse page: %w", err)
+	}
+
+	page := extract(doc, pageURL)
+	rawJSON, err := json.Marshal(page)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode scraped content: %w", err)
+	}
+
+	return &ScrapeResult{
+		Content: page.ScrapedContent,
+		RawJSON: string(rawJSON),
+	}, nil
+}
@@ -0,0 +1,61 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultReadyMaxBackoff caps the delay between readiness checks
+const defaultReadyMaxBackoff = 10 * time.Second
+
+// ReadyOptions controls how long WaitReady waits for a scraper to report healthy
+type ReadyOptions struct {
+	// Timeout is how long to keep checking before giving up (0 = a single check)
+	Timeout time.Duration
+	// Backoff is the delay before the second check, doubled for each later one (0 = 1s)
+	Backoff time.Duration
+	// MaxBackoff caps the delay between checks (0 = 10s)
+	MaxBackoff time.Duration
+	// OnWait is called before waiting for the next check (optional)
+	OnWait func(attempt int, err error, wait time.Duration)
+}
+
+// WaitReady checks the scraper's health until it succeeds or the timeout runs out,
+// so a scraper service that is still starting up doesn't fail the run
+func WaitReady(ctx context.Context, s Scraper, options ReadyOptions) (*HealthResponse, error) {
+	maxBackoff := options.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultReadyMaxBackoff
+	}
+	backoff := RetryPolicy{Backoff: options.Backoff, MaxBackoff: maxBackoff}
+	deadline := time.Now().Add(options.Timeout)
+
+	for n := 0; ; n++ {
+		health, err := s.Health(ctx)
+		if err == nil {
+			return health, nil
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 || ctx.Err() != nil {
+			if n == 0 {
+				return nil, err
+			}
+			return nil, fmt.Errorf("not ready after %s (%d checks): %w", options.Timeout, n+1, err)
+		}
+
+		wait := min(backoff.delay(n), remaining)
+		if options.OnWait != nil {
+			options.OnWait(n+1, err, wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package scraper
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/httpfetch"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/urlpolicy"
+)
+
+const (
+	defaultRetryBackoff    = time.Second
+	defaultRetryMaxBackoff = 30 * time.Second
+)
+
+// retryableStatus are the HTTP statuses worth another attempt
+var retryableStatus = map[int]bool{
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// RetryPolicy controls how scrapes that fail with a transient error are retried
+type RetryPolicy struct {
+	// Retries is the number of attempts made after the first one (0 = no retries)
+	Retries int
+	// Backoff is the delay before the first retry, doubled for each later one (0 = 1s)
+	Backoff time.Duration
+	// MaxBackoff caps the delay between attempts (0 = 30s)
+	MaxBackoff time.Duration
+	// RetryTimeouts also retries attempts that timed out; off by default, since a page
+	// that hit the full scrape timeout is usually just as slow the next time
+	RetryTimeouts bool
+	// OnRetry is called before waiting for the next attempt (optional)
+	OnRetry func(attempt int, err error, wait time.Duration)
+}
+
+// run calls attempt until it succeeds, fails with a permanent error or the retries run out
+func (r RetryPolicy) run(ctx context.Context, attempt func() error) error {
+	for n := 0; ; n++ {
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+
+		ok, retryAfter := Retryable(err)
+		if !ok && r.RetryTimeouts && isTimeout(err) {
+			ok = true
+		}
+		if !ok || n >= r.Retries || ctx.Err() != nil {
+			return err
+		}
+
+		// Honour Retry-After, but never wait longer than the configured maximum
+		wait := max(r.delay(n), min(retryAfter, r.maxBackoff()))
+		if r.OnRetry != nil {
+			r.OnRetry(n+1, err, wait)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// delay returns the jittered backoff before retry n (0-based): half of the exponential
+// delay is fixed and the other half random, so concurrent runs don't retry in lockstep
+func (r RetryPolicy) delay(n int) time.Duration {
+	backoff := r.Backoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	maxBackoff := r.maxBackoff()
+
+	delay := backoff
+	for i := 0; i < n && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxBackoff)
+
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// maxBackoff returns the cap on delays between attempts
+func (r RetryPolicy) maxBackoff() time.Duration {
+	if r.MaxBackoff <= 0 {
+		return defaultRetryMaxBackoff
+	}
+	return r.MaxBackoff
+}
+
+// Retryable reports whether a scrape error is transient (connection failures, 429 and 5xx
+// responses) and any delay the server asked for before retrying. Timeouts, TLS and
+// certificate failures and unknown hosts are not.
+func Retryable(err error) (bool, time.Duration) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, urlpolicy.ErrBlocked) || isTimeout(err) {
+		return false, 0
+	}
+
+	var scraperStatus *StatusError
+	if errors.As(err, &scraperStatus) {
+		return retryableStatus[scraperStatus.StatusCode], scraperStatus.RetryAfter
+	}
+	var fetchStatus *httpfetch.StatusError
+	if errors.As(err, &fetchStatus) {
+		return retryableStatus[fetchStatus.StatusCode], fetchStatus.RetryAfter
+	}
+
+	// Certificate and handshake failures won't fix themselves between attempts
+	var certErr *tls.CertificateVerificationError
+	var alertErr tls.AlertError
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	if errors.As(err, &certErr) || errors.As(err, &alertErr) || errors.As(err, &unknownAuthority) || errors.As(err, &hostnameErr) {
+		return false, 0
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary, 0
+	}
+
+	// Refused or reset connections and connections closed mid-response
+	retryable := errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+	return retryable, 0
+}
+
+// isTimeout reports whether an attempt failed because it ran out of time
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,23 @@
+package scraper
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraperules"
+)
+
+// ErrSkipped is returned for URLs a scrape rule says not to scrape
+var ErrSkipped = errors.New("skipped by scrape rule")
+
+// applyRules returns the rule matching a URL (nil if none) and the URL to scrape
+func applyRules(rules *scraperules.Rules, url string) (*scraperules.Rule, string, error) {
+	rule := rules.Match(url)
+	if rule == nil {
+		return nil, url, nil
+	}
+	if rule.Skip {
+		return nil, "", fmt.Errorf("%w %s", ErrSkipped, rule.Name)
+	}
+	return rule, rule.RewriteURL(url), nil
+}
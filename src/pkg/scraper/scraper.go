@@ -0,0 +1,73 @@
+package scraper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Backend selects which scraper implementation extracts page content
+type Backend string
+
+const (
+	// BackendWebmeatscraper sends pages to the external webmeatscraper service
+	BackendWebmeatscraper Backend = "webmeatscraper"
+	// BackendNative fetches and parses pages in-process
+	BackendNative Backend = "native"
+)
+
+// ParseBackend parses a scraper backend name, returning an error for unknown backends
+func ParseBackend(value string) (Backend, error) {
+	switch backend := Backend(strings.ToLower(strings.TrimSpace(value))); backend {
+	case BackendWebmeatscraper, BackendNative:
+		return backend, nil
+	default:
+		return "", fmt.Errorf("unknown scraper backend %q (expected webmeatscraper or native)", value)
+	}
+}
+
+// Lifecycle decides who starts the scraper service and whether it is told to exit after a run
+type Lifecycle string
+
+const (
+	// LifecycleDedicated always sends exit at the end of a run (the scraper serves only this processor)
+	LifecycleDedicated Lifecycle = "dedicated"
+	// LifecycleExternal never sends exit; the scraper is run and stopped by someone else
+	LifecycleExternal Lifecycle = "external"
+	// LifecycleShared starts the scraper command when no scraper answers, and only stops a scraper it started
+	LifecycleShared Lifecycle = "shared"
+	// LifecycleManaged always runs the scraper command, restarts it when it crashes and stops it at the end
+	LifecycleManaged Lifecycle = "managed"
+)
+
+// ParseLifecycle parses a scraper lifecycle name
+func ParseLifecycle(value string) (Lifecycle, error) {
+	switch lifecycle := Lifecycle(strings.ToLower(strings.TrimSpace(value))); lifecycle {
+	case LifecycleDedicated, LifecycleExternal, LifecycleShared, LifecycleManaged:
+		return lifecycle, nil
+	default:
+		return "", fmt.Errorf("unknown scraper lifecycle %q (expected dedicated, external, shared or managed)", value)
+	}
+}
+
+// Scraper extracts the content and metadata of a page
+type Scraper interface {
+	// Scrape returns the parsed content of a URL together with the raw JSON it was decoded from
+	Scrape(ctx context.Context, url string) (*ScrapeResult, error)
+	// Health reports whether the scraper can accept requests
+	Health(ctx context.Context) (*HealthResponse, error)
+	// Name describes the scraper in log output
+	Name() string
+}
+
+// Exiter is implemented by scrapers backed by a service that should be shut down after a run
+type Exiter interface {
+	Exit(ctx context.Context) error
+}
+
+// Compile-time checks that both backends satisfy the interfaces
+var (
+	_ Scraper = (*Client)(nil)
+	_ Exiter  = (*Client)(nil)
+	_ Scraper = (*Native)(nil)
+)
@@ -0,0 +1,226 @@
+package scraper
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/textutil"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// skippedElements never contribute to the extracted text
+var skippedElements = map[atom.Atom]bool{
+	atom.Head:     true,
+	atom.Script:   true,
+	atom.Style:    true,
+	atom.Noscript: true,
+	atom.Template: true,
+	atom.Svg:      true,
+	atom.Nav:      true,
+	atom.Aside:    true,
+	atom.Form:     true,
+	atom.Iframe:   true,
+	atom.Button:   true,
+	atom.Select:   true,
+	atom.Textarea: true,
+}
+
+// inlineElements are joined with surrounding text into a single paragraph
+var inlineElements = map[atom.Atom]bool{
+	atom.A:      true,
+	atom.Abbr:   true,
+	atom.B:      true,
+	atom.Br:     true,
+	atom.Cite:   true,
+	atom.Code:   true,
+	atom.Em:     true,
+	atom.I:      true,
+	atom.Mark:   true,
+	atom.Q:      true,
+	atom.S:      true,
+	atom.Small:  true,
+	atom.Span:   true,
+	atom.Strong: true,
+	atom.Sub:    true,
+	atom.Sup:    true,
+	atom.Time:   true,
+	atom.U:      true,
+}
+
+// headingLevels maps heading elements to their Markdown level
+var headingLevels = map[atom.Atom]int{
+	atom.H1: 1, atom.H2: 2, atom.H3: 3, atom.H4: 4, atom.H5: 5, atom.H6: 6,
+}
+
+// textWriter collects the Markdown blocks of a document's main content
+type textWriter struct {
+	base   *url.URL
+	blocks []string
+	// skipChrome drops page headers and footers when no article or main element was found
+	skipChrome bool
+}
+
+// extractText renders the main content of a document (its <article>, <main> or <body>)
+// as Markdown paragraphs, headings, list items and images
+func extractText(doc *html.Node, base *url.URL) string {
+	root := findElement(doc, func(n *html.Node) bool { return n.DataAtom == atom.Article })
+	if root == nil {
+		root = findElement(doc, func(n *html.Node) bool {
+			return n.DataAtom == atom.Main || getAttr(n, "role") == "main"
+		})
+	}
+
+	w := &textWriter{base: base}
+	if root == nil {
+		root = findElement(doc, func(n *html.Node) bool { return n.DataAtom == atom.Body })
+		w.skipChrome = true
+	}
+	if root == nil {
+		return ""
+	}
+
+	w.container(root)
+	return strings.Join(w.blocks, "\n\n")
+}
+
+// container writes the children of a generic element, grouping runs of inline content into paragraphs
+func (w *textWriter) container(n *html.Node) {
+	var inline strings.Builder
+	flush := func() {
+		w.add(inline.String())
+		inline.Reset()
+	}
+
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		switch {
+		case child.Type == html.TextNode:
+			inline.WriteString(child.Data)
+		case child.Type != html.ElementNode:
+			continue
+		case inlineElements[child.DataAtom]:
+			inline.WriteString(inlineText(child))
+			w.images(child)
+		default:
+			flush()
+			w.element(child)
+		}
+	}
+	flush()
+}
+
+// element writes a block-level element
+func (w *textWriter) element(n *html.Node) {
+	if skippedElements[n.DataAtom] {
+		return
+	}
+	if w.skipChrome && (n.DataAtom == atom.Header || n.DataAtom == atom.Footer) {
+		return
+	}
+
+	if level, ok := headingLevels[n.DataAtom]; ok {
+		if text := textutil.CollapseSpace(inlineText(n)); text != "" {
+			w.add(strings.Repeat("#", level) + " " + text)
+		}
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.P, atom.Figcaption, atom.Dt, atom.Dd, atom.Td, atom.Th:
+		w.add(inlineText(n))
+		w.images(n)
+	case atom.Li:
+		if text := textutil.CollapseSpace(inlineText(n)); text != "" {
+			w.add("- " + text)
+		}
+		w.images(n)
+	case atom.Blockquote:
+		if text := textutil.CollapseSpace(inlineText(n)); text != "" {
+			w.add("> " + text)
+		}
+	case atom.Pre:
+		if text := strings.Trim(textContent(n), "\n"); strings.TrimSpace(text) != "" {
+			w.blocks = append(w.blocks, "```\n"+text+"\n```")
+		}
+	case atom.Img:
+		w.image(n)
+	default:
+		w.container(n)
+	}
+}
+
+// images writes the images nested inside an element whose text was already written
+func (w *textWriter) images(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type != html.ElementNode || skippedElements[child.DataAtom] {
+			continue
+		}
+		if child.DataAtom == atom.Img {
+			w.image(child)
+			continue
+		}
+		w.images(child)
+	}
+}
+
+// image writes an <img> as a Markdown image, skipping inline data URLs
+func (w *textWriter) image(n *html.Node) {
+	src := textutil.FirstNonEmpty(getAttr(n, "src"), getAttr(n, "data-src"))
+	if src == "" || strings.HasPrefix(src, "data:") {
+		return
+	}
+	if w.base != nil {
+		resolved, err := w.base.Parse(src)
+		if err != nil {
+			return
+		}
+		src = resolved.String()
+	}
+	alt := strings.NewReplacer("[", "", "]", "").Replace(textutil.CollapseSpace(getAttr(n, "alt")))
+	w.blocks = append(w.blocks, "!["+alt+"]("+src+")")
+}
+
+// add appends a paragraph with collapsed whitespace, ignoring blank ones
+func (w *textWriter) add(text string) {
+	if text = textutil.CollapseSpace(text); text != "" {
+		w.blocks = append(w.blocks, text)
+	}
+}
+
+// inlineText returns the visible text below an element
+func inlineText(n *html.Node) string {
+	var b strings.Builder
+	var visit func(*html.Node)
+	visit = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			b.WriteString(node.Data)
+			return
+		case html.ElementNode:
+			if skippedElements[node.DataAtom] {
+				return
+			}
+			if node.DataAtom == atom.Br {
+				b.WriteString(" ")
+			}
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(n)
+	return b.String()
+}
+
+// findElement returns the first element, in document order, matching the predicate
+func findElement(n *html.Node, match func(*html.Node) bool) *html.Node {
+	if n.Type == html.ElementNode && match(n) {
+		return n
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if found := findElement(child, match); found != nil {
+			return found
+		}
+	}
+	return nil
+}
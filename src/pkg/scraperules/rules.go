@@ -0,0 +1,223 @@
+package scraperules
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/textutil"
+)
+
+// Rule changes how the bookmarks matching it are scraped
+// A rule matches when every criterion it sets matches; the first matching rule applies
+type Rule struct {
+	// Name identifies the rule in log output (defaults to its match criteria)
+	Name string
+	// Host is a glob over the host name ("*.example.com"); a plain host also matches its subdomains
+	Host string
+	// URL is a glob over the full URL ("*" matches any run of characters, including "/")
+	URL string
+	// Regex is a regular expression over the full URL
+	Regex string
+
+	// Skip leaves the page unscraped; the bookmark is marked processed
+	Skip bool
+	// Timeout replaces the scrape timeout (0 = scraper default)
+	Timeout time.Duration
+	// Rewrite changes the URL sent to the scraper (the bookmark keeps its URL)
+	Rewrite *Rewrite
+	// Tag is added to the bookmark
+	Tag string
+	// Headers are sent with the page request (built-in scraper only)
+	Headers map[string]string
+
+	urlPattern   *regexp.Regexp
+	regexPattern *regexp.Regexp
+}
+
+// Rewrite replaces the parts of a URL matching Pattern with Replacement ($1 expands groups)
+type Rewrite struct {
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+
+	pattern *regexp.Regexp
+}
+
+// ruleFile is the JSON layout of a rule
+type ruleFile struct {
+	Name    string            `json:"name,omitempty"`
+	Host    string            `json:"host,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Regex   string            `json:"regex,omitempty"`
+	Skip    bool              `json:"skip,omitempty"`
+	Timeout string            `json:"timeout,omitempty"`
+	Rewrite *Rewrite          `json:"rewrite,omitempty"`
+	Tag     string            `json:"tag,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Rules is an ordered list of scrape rules; a nil *Rules matches nothing
+type Rules struct {
+	rules []*Rule
+}
+
+// Load reads a rules file: {"rules": [{"host": "...", "timeout": "3m"}, ...]}
+func Load(filename string) (*Rules, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Rules []ruleFile `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filename, err)
+	}
+
+	rules := make([]*Rule, 0, len(file.Rules))
+	for i, raw := range file.Rules {
+		rule := &Rule{
+			Name:    raw.Name,
+			Host:    raw.Host,
+			URL:     raw.URL,
+			Regex:   raw.Regex,
+			Skip:    raw.Skip,
+			Rewrite: raw.Rewrite,
+			Tag:     strings.TrimSpace(raw.Tag),
+			Headers: raw.Headers,
+		}
+		if raw.Timeout != "" {
+			if rule.Timeout, err = time.ParseDuration(raw.Timeout); err != nil || rule.Timeout <= 0 {
+				return nil, fmt.Errorf("rule %d: invalid timeout %q", i+1, raw.Timeout)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return New(rules...)
+}
+
+// New checks the rules and prepares their patterns
+func New(rules ...*Rule) (*Rules, error) {
+	for i, rule := range rules {
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+	}
+	return &Rules{rules: rules}, nil
+}
+
+// compile checks the rule and builds its patterns
+func (r *Rule) compile() error {
+	if r.Host == "" && r.URL == "" && r.Regex == "" {
+		return fmt.Errorf("a rule needs host, url or regex")
+	}
+	if r.Host != "" {
+		if _, err := path.Match(strings.ToLower(r.Host), ""); err != nil {
+			return fmt.Errorf("invalid host pattern %q: %w", r.Host, err)
+		}
+	}
+	if r.URL != "" {
+		r.urlPattern = regexp.MustCompile(textutil.GlobToRegexp(r.URL))
+	}
+	if r.Regex != "" {
+		pattern, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid regex %q: %w", r.Regex, err)
+		}
+		r.regexPattern = pattern
+	}
+	if r.Rewrite != nil {
+		pattern, err := regexp.Compile(r.Rewrite.Pattern)
+		if err != nil || r.Rewrite.Pattern == "" {
+			return fmt.Errorf("invalid rewrite pattern %q", r.Rewrite.Pattern)
+		}
+		r.Rewrite.pattern = pattern
+	}
+	for name := range r.Headers {
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(name, " :\r\n") {
+			return fmt.Errorf("invalid header name %q", name)
+		}
+	}
+	if r.Name == "" {
+		r.Name = textutil.FirstNonEmpty(r.Host, r.URL, r.Regex)
+	}
+	return nil
+}
+
+// Match returns the first rule matching a URL, or nil
+func (r *Rules) Match(rawURL string) *Rule {
+	if r == nil {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	for _, rule := range r.rules {
+		if rule.matches(rawURL, u) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// HeaderRules returns the names of the rules that add headers
+func (r *Rules) HeaderRules() []string {
+	if r == nil {
+		return nil
+	}
+	var names []string
+	for _, rule := range r.rules {
+		if len(rule.Headers) > 0 {
+			names = append(names, rule.Name)
+		}
+	}
+	return names
+}
+
+// Len returns the number of rules
+func (r *Rules) Len() int {
+	if r == nil {
+		return 0
+	}
+	return len(r.rules)
+}
+
+// matches reports whether every criterion of the rule matches the URL
+func (r *Rule) matches(rawURL string, u *url.URL) bool {
+	if r.Host != "" && !hostMatches(r.Host, u.Hostname()) {
+		return false
+	}
+	if r.urlPattern != nil && !r.urlPattern.MatchString(rawURL) {
+		return false
+	}
+	if r.regexPattern != nil && !r.regexPattern.MatchString(rawURL) {
+		return false
+	}
+	return true
+}
+
+// RewriteURL returns the URL to scrape; it is unchanged without a rule or rewrite
+func (r *Rule) RewriteURL(rawURL string) string {
+	if r == nil || r.Rewrite == nil {
+		return rawURL
+	}
+	return r.Rewrite.pattern.ReplaceAllString(rawURL, r.Rewrite.Replacement)
+}
+
+// hostMatches matches a host glob; a pattern without wildcards also matches subdomains
+func hostMatches(pattern, host string) bool {
+	pattern = strings.ToLower(pattern)
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if !strings.ContainsAny(pattern, "*?[") {
+		return host == pattern || strings.HasSuffix(host, "."+pattern)
+	}
+	ok, _ := path.Match(pattern, host)
+	return ok
+}
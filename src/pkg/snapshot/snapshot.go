@@ -0,0 +1,247 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+)
+
+// ContentType is the MIME type of generated snapshots
+const ContentType = "text/html"
+
+// truncatedMarker is appended to the article body when it had to be shortened to fit the size limit
+const truncatedMarker = "\n\n[Snapshot truncated to fit the size limit]"
+
+// Snapshot is a self-contained HTML copy of a bookmarked page
+type Snapshot struct {
+	Filename  string
+	HTML      []byte
+	Truncated bool
+	// ImageDropped is set when the image was left out to fit the size limit
+	ImageDropped bool
+}
+
+// Image is an image embedded in the snapshot as a data: URI, so it needs no network
+type Image struct {
+	ContentType string
+	Data        []byte
+}
+
+// pageData is the data rendered into the snapshot template
+type pageData struct {
+	Title         string
+	URL           string
+	Author        string
+	Publisher     string
+	DatePublished string
+	Description   string
+	Image         template.URL // data: URI, or empty for no image
+	Paragraphs    []string
+	CapturedAt    string
+}
+
+var pageTemplate = template.Must(template.New("snapshot").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Helvetica, Arial, sans-serif; line-height: 1.6; color: #37352f; max-width: 760px; margin: 2rem auto; padding: 0 1rem; }
+header { border-bottom: 1px solid #e9e9e7; margin-bottom: 1.5rem; padding-bottom: 1rem; }
+h1 { font-size: 2rem; line-height: 1.25; margin: 0 0 .5rem; }
+.meta { color: #787774; font-size: .9rem; }
+.meta a { color: inherit; word-break: break-all; }
+.description { font-style: italic; color: #5a5a57; }
+img { max-width: 100%; height: auto; border-radius: 4px; }
+footer { border-top: 1px solid #e9e9e7; margin-top: 2rem; padding-top: 1rem; color: #9b9a97; font-size: .8rem; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<div class="meta">
+{{if .Author}}<div>By {{.Author}}{{if .Publisher}} · {{.Publisher}}{{end}}</div>{{else if .Publisher}}<div>{{.Publisher}}</div>{{end}}
+{{if .DatePublished}}<div>Published {{.DatePublished}}</div>{{end}}
+<div>Source: <a href="{{.URL}}">{{.URL}}</a></div>
+</div>
+</header>
+{{if .Image}}<p><img src="{{.Image}}" alt=""></p>{{end}}
+{{if .Description}}<p class="description">{{.Description}}</p>{{end}}
+<article>
+{{range .Paragraphs}}<p>{{.}}</p>
+{{end}}</article>
+<footer>Snapshot captured {{.CapturedAt}}</footer>
+</body>
+</html>
+`))
+
+var paragraphSplit = regexp.MustCompile(`\n\s*\n`)
+
+// Build renders a self-contained HTML snapshot of the scraped content
+// The image (optional) is embedded in the document; remote images are never referenced.
+// If maxBytes > 0 the article body is truncated so the document fits within the limit,
+// and the image is dropped when even the truncated document doesn't fit.
+func Build(pageURL string, content *scraper.ScrapedContent, image *Image, maxBytes int) (*Snapshot, error) {
+	if content == nil {
+		return nil, fmt.Errorf("scraped content is required")
+	}
+
+	data := newPageData(pageURL, content)
+	if image != nil && len(image.Data) > 0 {
+		data.Image = template.URL("data:" + image.ContentType + ";base64," + base64.StdEncoding.EncodeToString(image.Data))
+	}
+	body := strings.TrimSpace(content.Content)
+
+	var title string
+	if content.Metadata != nil {
+		title = content.Metadata.Title
+	}
+	snapshot := &Snapshot{Filename: Filename(title, pageURL)}
+
+	html, truncated, err := fit(data, body, maxBytes)
+	if err != nil && data.Image != "" {
+		data.Image = ""
+		snapshot.ImageDropped = true
+		html, truncated, err = fit(data, body, maxBytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.HTML = html
+	snapshot.Truncated = truncated
+	return snapshot, nil
+}
+
+// fit renders the document, truncating the body to fit within maxBytes (0 = no limit)
+func fit(data pageData, body string, maxBytes int) ([]byte, bool, error) {
+	html, err := render(data, body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if maxBytes <= 0 || len(html) <= maxBytes {
+		return html, false, nil
+	}
+
+	// Escaping makes the rendered size non-linear in the body length,
+	// so binary search for the longest body that still fits.
+	low, high := 0, len(body)
+	var best []byte
+	for low < high {
+		mid := (low + high + 1) / 2
+		candidate, err := render(data, truncateUTF8(body, mid)+truncatedMarker)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(candidate) <= maxBytes {
+			low = mid
+			best = candidate
+		} else {
+			high = mid - 1
+		}
+	}
+
+	if best == nil {
+		// Not even a single byte of the body fits; try the header alone
+		if candidate, err := render(data, truncatedMarker); err == nil && len(candidate) <= maxBytes {
+			best = candidate
+		}
+	}
+	if best == nil {
+		return nil, false, fmt.Errorf("snapshot exceeds the %d byte limit even without content", maxBytes)
+	}
+	return best, true, nil
+}
+
+// Filename returns a filesystem-safe .html filename derived from the title or URL host
+func Filename(title, pageURL string) string {
+	base := title
+	if base == "" {
+		if parsed, err := url.Parse(pageURL); err == nil && parsed.Host != "" {
+			base = parsed.Host
+		}
+	}
+
+	var b strings.Builder
+	lastDash := false
+	for _, r := range strings.ToLower(base) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			lastDash = false
+		case !lastDash && b.Len() > 0:
+			b.WriteByte('-')
+			lastDash = true
+		}
+		if b.Len() >= 80 {
+			break
+		}
+	}
+
+	slug := strings.Trim(b.String(), "-")
+	if slug == "" {
+		slug = "page"
+	}
+	return "snapshot-" + slug + ".html"
+}
+
+// newPageData collects the header fields from the scraped content
+func newPageData(pageURL string, content *scraper.ScrapedContent) pageData {
+	data := pageData{
+		URL:        pageURL,
+		CapturedAt: time.Now().UTC().Format(time.RFC1123),
+	}
+
+	if meta := content.Metadata; meta != nil {
+		data.Title = meta.Title
+		data.Author = meta.Author
+		data.Publisher = meta.Publisher
+		data.Description = meta.Description
+		if meta.DatePublished != nil {
+			data.DatePublished = meta.DatePublished.Format("January 2, 2006")
+		}
+	}
+
+	if data.Title == "" {
+		data.Title = pageURL
+	}
+
+	return data
+}
+
+// render executes the template with the given article body
+func render(data pageData, body string) ([]byte, error) {
+	data.Paragraphs = nil
+	for _, paragraph := range paragraphSplit.Split(body, -1) {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			data.Paragraphs = append(data.Paragraphs, paragraph)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pageTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render snapshot: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a multi-byte character
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
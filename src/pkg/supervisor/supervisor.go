@@ -0,0 +1,237 @@
+package supervisor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultMaxRestarts    = 5
+	defaultRestartBackoff = time.Second
+	maxRestartBackoff     = 30 * time.Second
+	defaultStopTimeout    = 10 * time.Second
+)
+
+// ErrGaveUp is reported once a crashing process has used up its restarts
+var ErrGaveUp = errors.New("process keeps crashing, giving up")
+
+// Options holds the optional settings of a supervised process
+type Options struct {
+	// Restart starts the process again when it exits on its own
+	Restart bool
+	// MaxRestarts limits the restarts of a process that keeps crashing (0 = 5)
+	MaxRestarts int
+	// RestartBackoff is the delay before the first restart, doubled for each later one (0 = 1s)
+	RestartBackoff time.Duration
+	// StopTimeout is how long Stop waits after SIGTERM before killing the process (0 = 10s)
+	StopTimeout time.Duration
+	// Output receives the process's stdout and stderr (nil = os.Stderr)
+	Output io.Writer
+	// OnExit is called when the process exits on its own, with the delay before the
+	// restart or zero when it won't be restarted (optional)
+	OnExit func(err error, restart int, wait time.Duration)
+}
+
+// Process is a command started, watched and stopped by the supervisor
+type Process struct {
+	command []string
+	options Options
+
+	mu       sync.Mutex
+	cmd      *exec.Cmd
+	exited   chan struct{} // closed when the current run of cmd has exited
+	stopping bool
+	restarts int
+	err      error
+	stop     chan struct{} // closed by Stop
+	done     chan struct{} // closed when the process is stopped or given up on
+}
+
+// ParseCommand splits a command line on whitespace (no shell quoting)
+func ParseCommand(value string) []string {
+	return strings.Fields(value)
+}
+
+// Start starts the command and supervises it until Stop is called
+func Start(command []string, options Options) (*Process, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("no command to start")
+	}
+	if options.MaxRestarts <= 0 {
+		options.MaxRestarts = defaultMaxRestarts
+	}
+	if options.RestartBackoff <= 0 {
+		options.RestartBackoff = defaultRestartBackoff
+	}
+	if options.StopTimeout <= 0 {
+		options.StopTimeout = defaultStopTimeout
+	}
+	if options.Output == nil {
+		options.Output = os.Stderr
+	}
+
+	p := &Process{
+		command: command,
+		options: options,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	p.mu.Lock()
+	err := p.start()
+	p.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	go p.supervise()
+	return p, nil
+}
+
+// start runs the command once; p.mu must be held
+func (p *Process) start() error {
+	cmd := exec.Command(p.command[0], p.command[1:]...)
+	cmd.Stdout = p.options.Output
+	cmd.Stderr = p.options.Output
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", p.command[0], err)
+	}
+	p.cmd = cmd
+	p.exited = make(chan struct{})
+	return nil
+}
+
+// supervise waits for each run of the command and restarts it when it exits on its own
+func (p *Process) supervise() {
+	defer close(p.done)
+
+	for {
+		p.mu.Lock()
+		cmd, exited := p.cmd, p.exited
+		p.mu.Unlock()
+
+		err := cmd.Wait()
+		close(exited)
+
+		p.mu.Lock()
+		if p.stopping {
+			p.mu.Unlock()
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("exited unexpectedly")
+		}
+		restart := p.options.Restart && p.restarts < p.options.MaxRestarts
+		if !restart {
+			if p.options.Restart {
+				err = fmt.Errorf("%w: %w", ErrGaveUp, err)
+			}
+			p.err = err
+			p.mu.Unlock()
+			if p.options.OnExit != nil {
+				p.options.OnExit(err, p.restarts, 0)
+			}
+			return
+		}
+		p.restarts++
+		wait := p.backoff(p.restarts)
+		p.mu.Unlock()
+
+		if p.options.OnExit != nil {
+			p.options.OnExit(err, p.restarts, wait)
+		}
+		select {
+		case <-time.After(wait):
+		case <-p.stop:
+			return
+		}
+
+		// Checked under the same lock as the restart, so Stop always sees the current run
+		p.mu.Lock()
+		if p.stopping {
+			p.mu.Unlock()
+			return
+		}
+		if err := p.start(); err != nil {
+			p.err = err
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+	}
+}
+
+// backoff returns the delay before restart n (1-based)
+func (p *Process) backoff(n int) time.Duration {
+	wait := p.options.RestartBackoff
+	for i := 1; i < n && wait < maxRestartBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, maxRestartBackoff)
+}
+
+// Stop asks the process to terminate, kills it if it doesn't within the stop timeout
+// and ends supervision
+func (p *Process) Stop(ctx context.Context) {
+	p.mu.Lock()
+	if p.stopping {
+		p.mu.Unlock()
+		<-p.done
+		return
+	}
+	p.stopping = true
+	close(p.stop)
+	cmd, exited := p.cmd, p.exited
+	p.mu.Unlock()
+
+	select {
+	case <-exited:
+	default:
+		// SIGTERM is not supported on Windows, where the process is killed right away
+		if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+			cmd.Process.Kill()
+		}
+
+		timer := time.NewTimer(p.options.StopTimeout)
+		defer timer.Stop()
+		select {
+		case <-exited:
+		case <-timer.C:
+			cmd.Process.Kill()
+			<-exited
+		case <-ctx.Done():
+			cmd.Process.Kill()
+			<-exited
+		}
+	}
+
+	<-p.done
+}
+
+// Wait blocks until the process has been stopped or given up on and returns why it ended
+// (nil after Stop)
+func (p *Process) Wait() error {
+	<-p.done
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// Done is closed once the process has been stopped or given up on
+func (p *Process) Done() <-chan struct{} {
+	return p.done
+}
+
+// Restarts returns how many times the process was restarted after crashing
+func (p *Process) Restarts() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.restarts
+}
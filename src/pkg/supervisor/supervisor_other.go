@@ -0,0 +1,8 @@
+//go:build !unix
+
+package supervisor
+
+import "os/exec"
+
+// setProcessGroup does nothing where process groups aren't available
+func setProcessGroup(cmd *exec.Cmd) {}
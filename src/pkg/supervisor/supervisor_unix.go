@@ -0,0 +1,14 @@
+//go:build unix
+
+package supervisor
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup runs the command in its own process group, so a Ctrl-C in the terminal
+// reaches only the supervising program, which decides when to stop the child
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
@@ -3,6 +3,7 @@ package bookmarks
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/jomei/notionapi"
 	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/notion"
@@ -10,16 +11,43 @@ import (
 
 // Service handles CRUD operations for bookmarks
 type Service struct {
-	client *notion.Client
+	client        *notion.Client
+	mediaProperty string
 }
 
 // NewService creates a new bookmarks service
 func NewService(client *notion.Client) *Service {
 	return &Service{
-		client: client,
+		client:        client,
+		mediaProperty: PropertyMedia,
 	}
 }
 
+// SetMediaProperty sets the name of the Files & media property read into Bookmark.Media
+func (s *Service) SetMediaProperty(name string) {
+	if name == "" {
+		name = PropertyMedia
+	}
+	s.mediaProperty = name
+}
+
+// MediaProperty returns the name of the Files & media property read into Bookmark.Media
+func (s *Service) MediaProperty() string {
+	return s.mediaProperty
+}
+
+// toBookmark converts a page, reading media from the configured property
+func (s *Service) toBookmark(page *notionapi.Page) (*Bookmark, error) {
+	bookmark, err := ToBookmark(page)
+	if err != nil || bookmark == nil {
+		return bookmark, err
+	}
+	if s.mediaProperty != PropertyMedia {
+		bookmark.Media = MediaFromPage(page, s.mediaProperty)
+	}
+	return bookmark, nil
+}
+
 // Create creates a new bookmark in Notion
 func (s *Service) Create(ctx context.Context, bookmark *Bookmark) (*Bookmark, error) {
 	if bookmark.Title == "" {
@@ -41,7 +69,7 @@ func (s *Service) Create(ctx context.Context, bookmark *Bookmark) (*Bookmark, er
 		return nil, notion.NewError("create bookmark", err, fmt.Sprintf("failed to create bookmark: %s", bookmark.Title))
 	}
 
-	return ToBookmark(page)
+	return s.toBookmark(page)
 }
 
 // Get retrieves a bookmark by its ID
@@ -55,7 +83,7 @@ func (s *Service) Get(ctx context.Context, id string) (*Bookmark, error) {
 		return nil, notion.NewError("get bookmark", err, fmt.Sprintf("failed to get bookmark with ID: %s", id))
 	}
 
-	return ToBookmark(page)
+	return s.toBookmark(page)
 }
 
 // Update updates an existing bookmark
@@ -75,7 +103,7 @@ func (s *Service) Update(ctx context.Context, id string, bookmark *Bookmark) (*B
 		return nil, notion.NewError("update bookmark", err, fmt.Sprintf("failed to update bookmark with ID: %s", id))
 	}
 
-	return ToBookmark(page)
+	return s.toBookmark(page)
 }
 
 // Delete archives a bookmark (Notion doesn't support true deletion)
@@ -201,7 +229,7 @@ func (s *Service) List(ctx context.Context, filter *Filter) ([]*Bookmark, error)
 
 	bookmarks := make([]*Bookmark, 0, len(resp.Results))
 	for _, page := range resp.Results {
-		bookmark, err := ToBookmark(&page)
+		bookmark, err := s.toBookmark(&page)
 		if err != nil {
 			continue // Skip invalid bookmarks
 		}
@@ -274,7 +302,7 @@ func (s *Service) Query(ctx context.Context, options *QueryOptions) ([]*Bookmark
 
 	bookmarks := make([]*Bookmark, 0, len(resp.Results))
 	for _, page := range resp.Results {
-		bookmark, err := ToBookmark(&page)
+		bookmark, err := s.toBookmark(&page)
 		if err != nil {
 			continue // Skip invalid bookmarks
 		}
@@ -352,8 +380,8 @@ func (s *Service) MarkAsProcessed(ctx context.Context, bookmarkID string) (*Book
 	return s.Update(ctx, bookmarkID, bookmark)
 }
 
-// SetError sets an error message for a bookmark and marks it as not processed
-func (s *Service) SetError(ctx context.Context, bookmarkID string, errorMsg string) (*Bookmark, error) {
+// SetError sets an error message for a bookmark, adds the given tags and marks it as not processed
+func (s *Service) SetError(ctx context.Context, bookmarkID string, errorMsg string, tagIDs ...string) (*Bookmark, error) {
 	bookmark, err := s.Get(ctx, bookmarkID)
 	if err != nil {
 		return nil, err
@@ -361,6 +389,11 @@ func (s *Service) SetError(ctx context.Context, bookmarkID string, errorMsg stri
 
 	bookmark.Error = errorMsg
 	bookmark.Processed = false
+	for _, tagID := range tagIDs {
+		if !slices.Contains(bookmark.TagIDs, tagID) {
+			bookmark.TagIDs = append(bookmark.TagIDs, tagID)
+		}
+	}
 
 	return s.Update(ctx, bookmarkID, bookmark)
 }
@@ -387,7 +420,7 @@ func (s *Service) GetUnprocessed(ctx context.Context, limit int) ([]*Bookmark, e
 
 	bookmarks := make([]*Bookmark, 0, len(resp.Results))
 	for _, page := range resp.Results {
-		bookmark, err := ToBookmark(&page)
+		bookmark, err := s.toBookmark(&page)
 		if err != nil {
 			continue // Skip invalid bookmarks
 		}
@@ -419,7 +452,7 @@ func (s *Service) GetWithErrors(ctx context.Context, limit int) ([]*Bookmark, er
 
 	bookmarks := make([]*Bookmark, 0, len(resp.Results))
 	for _, page := range resp.Results {
-		bookmark, err := ToBookmark(&page)
+		bookmark, err := s.toBookmark(&page)
 		if err != nil {
 			continue
 		}
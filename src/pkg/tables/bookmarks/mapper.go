@@ -19,6 +19,16 @@ func ToBookmark(page *notionapi.Page) (*Bookmark, error) {
 		UpdatedAt: time.Time(page.LastEditedTime),
 	}
 
+	// Extract current cover and icon (read-only, set with notion.Client.UpdatePage)
+	if page.Cover != nil {
+		if bookmark.Cover = page.Cover.GetURL(); bookmark.Cover == "" {
+			bookmark.Cover = string(page.Cover.Type)
+		}
+	}
+	if page.Icon != nil {
+		bookmark.Icon = iconValue(page.Icon)
+	}
+
 	// Extract Title (page field)
 	if titleProp, ok := page.Properties[PropertyPage].(*notionapi.TitleProperty); ok {
 		bookmark.Title = notion.GetTitleText(titleProp.Title)
@@ -93,9 +103,43 @@ func ToBookmark(page *notionapi.Page) (*Bookmark, error) {
 		bookmark.Error = notion.RichTextToString(errorProp.RichText)
 	}
 
+	// Extract Media files
+	bookmark.Media = MediaFromPage(page, PropertyMedia)
+
 	return bookmark, nil
 }
 
+// iconValue returns an icon's URL or emoji; icon types notionapi doesn't model keep their type name
+func iconValue(icon *notionapi.Icon) string {
+	if icon.Emoji != nil {
+		return string(*icon.Emoji)
+	}
+	if url := icon.GetURL(); url != "" {
+		return url
+	}
+	return string(icon.Type)
+}
+
+// MediaFromPage reads the files of a Files & media property
+func MediaFromPage(page *notionapi.Page, property string) []MediaFile {
+	filesProp, ok := page.Properties[property].(*notionapi.FilesProperty)
+	if !ok {
+		return nil
+	}
+
+	media := make([]MediaFile, 0, len(filesProp.Files))
+	for _, file := range filesProp.Files {
+		entry := MediaFile{Name: file.Name}
+		if file.File != nil {
+			entry.URL = file.File.URL
+		} else if file.External != nil {
+			entry.URL = file.External.URL
+		}
+		media = append(media, entry)
+	}
+	return media
+}
+
 // ToNotionProperties converts a Bookmark to Notion page properties
 func ToNotionProperties(bookmark *Bookmark) notionapi.Properties {
 	props := notionapi.Properties{}
@@ -189,6 +233,8 @@ func ToNotionProperties(bookmark *Bookmark) notionapi.Properties {
 		Checkbox: bookmark.Processed,
 	}
 
+	// Media is not written here: file uploads are attached with notion.Client.SetFilesProperty
+
 	// Set Error field (can be empty string)
 	props[PropertyError] = notionapi.RichTextProperty{
 		RichText: notion.StringToRichText(bookmark.Error),
@@ -15,11 +15,14 @@ type Bookmark struct {
 	DateProcessed time.Time
 	Author        string
 	ImageURL      string
-	DatePublished string   // Date published as string from rich_text
-	ManualListIDs []string // Related manual list IDs
-	SmartListIDs  []string // Related smart list IDs
-	Processed     bool     // Whether the bookmark has been processed
-	Error         string   // Error message if processing failed
+	DatePublished string      // Date published as string from rich_text
+	ManualListIDs []string    // Related manual list IDs
+	SmartListIDs  []string    // Related smart list IDs
+	Processed     bool        // Whether the bookmark has been processed
+	Error         string      // Error message if processing failed
+	Media         []MediaFile // Files in the media property (uploaded cover and favicon)
+	Cover         string      // Current page cover URL ("" when the page has no cover)
+	Icon          string      // Current page icon: URL or emoji ("" when the page has no icon)
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 }
@@ -39,8 +42,15 @@ const (
 	PropertySmartLists    = "smart_lists"
 	PropertyProcessed     = "processed"
 	PropertyError         = "error"
+	PropertyMedia         = "media" // Default Files & media property, see Service.SetMediaProperty
 )
 
+// MediaFile is a file stored in a Files & media property
+type MediaFile struct {
+	Name string
+	URL  string // Notion-hosted files have a signed URL that expires after an hour
+}
+
 // Filter defines filtering options for listing bookmarks
 type Filter struct {
 	TitleContains   string
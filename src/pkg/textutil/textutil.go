@@ -0,0 +1,39 @@
+package textutil
+
+import (
+	"regexp"
+	"strings"
+)
+
+// FirstNonEmpty returns the first value that isn't blank, trimmed
+func FirstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// CollapseSpace joins the words of a string with single spaces
+func CollapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// GlobToRegexp anchors a glob and turns "*" and "?" into their regular expression equivalents
+func GlobToRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
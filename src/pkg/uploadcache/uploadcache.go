@@ -0,0 +1,229 @@
+package uploadcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// fileVersion is bumped when the on-disk format changes; older files are discarded
+const fileVersion = 1
+
+// Kind separates covers from icons, since the same URL is transcoded differently for each
+type Kind string
+
+const (
+	KindCover Kind = "cover"
+	KindIcon  Kind = "icon"
+)
+
+// Entry maps an image to a file upload that has already been attached to a page.
+// Attached uploads can be referenced again by ID, so a cached entry lets another page
+// reuse (copy) the same cover or icon without importing the image again.
+type Entry struct {
+	Kind         Kind      `json:"kind"`
+	URL          string    `json:"url"`
+	ContentHash  string    `json:"content_hash,omitempty"`
+	FileUploadID string    `json:"file_upload_id"`
+	PageID       string    `json:"page_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// cacheFile is the JSON document stored on disk
+type cacheFile struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// Cache is a persistent, concurrency-safe index of attached file uploads
+// keyed by image URL and by content hash
+type Cache struct {
+	path string
+
+	mu      sync.Mutex
+	entries []*Entry
+	byURL   map[string]*Entry
+	byHash  map[string]*Entry
+}
+
+// Open loads the cache stored at path; a missing or outdated file yields an empty cache
+func Open(path string) (*Cache, error) {
+	c := &Cache{
+		path:   path,
+		byURL:  make(map[string]*Entry),
+		byHash: make(map[string]*Entry),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload cache: %w", err)
+	}
+
+	var file cacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse upload cache %s: %w", path, err)
+	}
+	if file.Version != fileVersion {
+		return c, nil
+	}
+
+	for i := range file.Entries {
+		c.index(&file.Entries[i])
+	}
+	return c, nil
+}
+
+// Len returns the number of cached entries
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// LookupURL returns the entry for an image URL
+func (c *Cache) LookupURL(kind Kind, url string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.byURL[key(kind, url)]
+	if !ok {
+		return Entry{}, false
+	}
+	return *entry, true
+}
+
+// LookupHash returns the entry for image content with the given hash
+func (c *Cache) LookupHash(kind Kind, hash string) (Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.byHash[key(kind, hash)]
+	if !ok {
+		return Entry{}, false
+	}
+	return *entry, true
+}
+
+// Put records an attached file upload, replacing entries for the same URL or hash,
+// and writes the cache to disk
+func (c *Cache) Put(entry Entry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.index(&entry)
+	return c.saveLocked()
+}
+
+// Forget drops every entry pointing at a file upload (e.g. one Notion no longer accepts)
+// and writes the cache to disk
+func (c *Cache) Forget(fileUploadID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	kept := c.entries[:0]
+	removed := false
+	for _, entry := range c.entries {
+		if entry.FileUploadID == fileUploadID {
+			removed = true
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	if !removed {
+		return nil
+	}
+
+	c.entries = nil
+	c.byURL = make(map[string]*Entry)
+	c.byHash = make(map[string]*Entry)
+	for _, entry := range kept {
+		c.index(entry)
+	}
+	return c.saveLocked()
+}
+
+// index adds an entry to the lookup maps; entries it shadows completely are dropped
+func (c *Cache) index(entry *Entry) {
+	if old, ok := c.byURL[key(entry.Kind, entry.URL)]; ok {
+		c.drop(old)
+	}
+	if entry.ContentHash != "" {
+		if old, ok := c.byHash[key(entry.Kind, entry.ContentHash)]; ok && old.URL == entry.URL {
+			c.drop(old)
+		}
+	}
+
+	c.entries = append(c.entries, entry)
+	c.byURL[key(entry.Kind, entry.URL)] = entry
+	if entry.ContentHash != "" {
+		c.byHash[key(entry.Kind, entry.ContentHash)] = entry
+	}
+}
+
+// drop removes an entry from the entry list and any map slot still pointing at it
+func (c *Cache) drop(entry *Entry) {
+	for i, e := range c.entries {
+		if e == entry {
+			c.entries = append(c.entries[:i], c.entries[i+1:]...)
+			break
+		}
+	}
+	if c.byURL[key(entry.Kind, entry.URL)] == entry {
+		delete(c.byURL, key(entry.Kind, entry.URL))
+	}
+	if c.byHash[key(entry.Kind, entry.ContentHash)] == entry {
+		delete(c.byHash, key(entry.Kind, entry.ContentHash))
+	}
+}
+
+// saveLocked writes the cache atomically (temp file + rename); c.mu must be held
+func (c *Cache) saveLocked() error {
+	file := cacheFile{Version: fileVersion, Entries: make([]Entry, 0, len(c.entries))}
+	for _, entry := range c.entries {
+		file.Entries = append(file.Entries, *entry)
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode upload cache: %w", err)
+	}
+
+	if dir := filepath.Dir(c.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create upload cache directory: %w", err)
+		}
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write upload cache: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to write upload cache: %w", err)
+	}
+	return nil
+}
+
+// HashContent returns the hex SHA-256 of image bytes
+func HashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// key builds a map key scoped by kind
+func key(kind Kind, value string) string {
+	return string(kind) + "|" + value
+}
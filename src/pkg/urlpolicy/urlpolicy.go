@@ -0,0 +1,240 @@
+package urlpolicy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// DefaultMaxRedirects is the redirect limit used when none is configured
+const DefaultMaxRedirects = 5
+
+// ErrBlocked is wrapped by every error returned for a URL the policy rejects
+var ErrBlocked = errors.New("blocked by URL policy")
+
+// blockedNetworks are special-purpose ranges not covered by the net.IP helpers
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // "this" network
+	"100.64.0.0/10", // carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"240.0.0.0/4",   // reserved
+	"64:ff9b::/96",  // NAT64, can embed private IPv4 addresses
+)
+
+// Options configures a Policy
+type Options struct {
+	// AllowHosts, when non-empty, is the only set of hosts that may be fetched
+	AllowHosts []string
+	// DenyHosts are never fetched; entries match the host and its subdomains
+	DenyHosts []string
+	// AllowPrivateNetworks permits loopback, private and link-local addresses
+	AllowPrivateNetworks bool
+	// MaxRedirects limits redirects followed by local fetches (0 = DefaultMaxRedirects, negative = none)
+	MaxRedirects int
+	// SkipResolve makes Check validate URLs without resolving their hosts, for replayed
+	// runs that never reach the network
+	SkipResolve bool
+}
+
+// Policy decides which URLs may be fetched locally or handed to Notion's importer
+type Policy struct {
+	allowHosts   []string
+	denyHosts    []string
+	allowPrivate bool
+	maxRedirects int
+	skipResolve  bool
+}
+
+// New creates a URL policy
+func New(options Options) *Policy {
+	maxRedirects := options.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = DefaultMaxRedirects
+	} else if maxRedirects < 0 {
+		maxRedirects = 0
+	}
+
+	return &Policy{
+		allowHosts:   normalizeHosts(options.AllowHosts),
+		denyHosts:    normalizeHosts(options.DenyHosts),
+		allowPrivate: options.AllowPrivateNetworks,
+		maxRedirects: maxRedirects,
+		skipResolve:  options.SkipResolve,
+	}
+}
+
+// ParseHostList splits a comma-separated host list from configuration
+func ParseHostList(value string) []string {
+	var hosts []string
+	for _, host := range strings.Split(value, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// CheckURL validates the scheme and host of a URL without resolving it
+func (p *Policy) CheckURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: invalid URL: %v", ErrBlocked, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("%w: scheme %q is not http(s)", ErrBlocked, parsed.Scheme)
+	}
+	if parsed.User != nil {
+		return fmt.Errorf("%w: URLs with credentials are not fetched", ErrBlocked)
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrBlocked)
+	}
+	if matchHost(p.denyHosts, host) {
+		return fmt.Errorf("%w: host %s is denied", ErrBlocked, host)
+	}
+	if len(p.allowHosts) > 0 && !matchHost(p.allowHosts, host) {
+		return fmt.Errorf("%w: host %s is not in the allow list", ErrBlocked, host)
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		if !p.allowPrivate {
+			return fmt.Errorf("%w: host %s is local", ErrBlocked, host)
+		}
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		return p.CheckIP(ip)
+	}
+	return nil
+}
+
+// Check validates a URL and every address its host resolves to
+// Used before handing a URL to Notion, which fetches it from outside our network checks
+func (p *Policy) Check(ctx context.Context, rawURL string) error {
+	if err := p.CheckURL(rawURL); err != nil {
+		return err
+	}
+	if p.allowPrivate || p.skipResolve {
+		return nil
+	}
+
+	parsed, _ := url.Parse(rawURL)
+	host := parsed.Hostname()
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if err := p.CheckIP(addr.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckIP rejects loopback, private, link-local and other special-purpose addresses
+func (p *Policy) CheckIP(ip net.IP) error {
+	if p.allowPrivate {
+		return nil
+	}
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+
+	switch {
+	case ip.IsLoopback(), ip.IsPrivate(), ip.IsUnspecified(),
+		ip.IsLinkLocalUnicast(), ip.IsLinkLocalMulticast(),
+		ip.IsInterfaceLocalMulticast(), ip.IsMulticast():
+		return fmt.Errorf("%w: address %s is not public", ErrBlocked, ip)
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return fmt.Errorf("%w: address %s is not public", ErrBlocked, ip)
+		}
+	}
+	return nil
+}
+
+// Transport returns an HTTP transport that checks every address it connects to,
+// so DNS answers that change between the check and the request (rebinding) are caught too
+// Proxies from the environment are not used, since they would hide the real destination
+func (p *Policy) Transport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("%w: unresolved address %s", ErrBlocked, address)
+			}
+			return p.CheckIP(ip)
+		},
+	}
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// CheckRedirect is an http.Client CheckRedirect hook enforcing the redirect limit
+// and validating every redirect target
+func (p *Policy) CheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > p.maxRedirects {
+		return fmt.Errorf("%w: stopped after %d redirects", ErrBlocked, p.maxRedirects)
+	}
+	return p.CheckURL(req.URL.String())
+}
+
+// matchHost reports whether host equals an entry or is a subdomain of one
+func matchHost(entries []string, host string) bool {
+	for _, entry := range entries {
+		if host == entry || strings.HasSuffix(host, "."+entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeHosts lowercases host entries and strips "*." wildcards and trailing dots
+func normalizeHosts(hosts []string) []string {
+	normalized := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		host = strings.TrimPrefix(host, "*.")
+		host = strings.TrimSuffix(host, ".")
+		if host != "" {
+			normalized = append(normalized, host)
+		}
+	}
+	return normalized
+}
+
+// mustParseCIDRs parses a fixed list of networks
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
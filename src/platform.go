@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/notion"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/platforms"
+)
+
+// platformProperty is a platform field mapping whose Notion property type is known
+type platformProperty struct {
+	platforms.Mapping
+	Type string
+}
+
+// resolvePlatformProperties looks up the type of every mapped property in the bookmarks database
+// Mappings to properties that don't exist are returned as missing and left out
+func resolvePlatformProperties(ctx context.Context, notionClient *notion.Client, databaseID string, mappings []platforms.Mapping) ([]platformProperty, []string, error) {
+	types, err := notionClient.DatabaseProperties(ctx, databaseID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read bookmarks database properties: %w", err)
+	}
+
+	var resolved []platformProperty
+	var missing []string
+	for _, mapping := range mappings {
+		propertyType, ok := types[mapping.Property]
+		if !ok {
+			missing = append(missing, mapping.Property)
+			continue
+		}
+		resolved = append(resolved, platformProperty{Mapping: mapping, Type: propertyType})
+	}
+	sort.Strings(missing)
+	return resolved, missing, nil
+}
+
+// platformPropertyValues builds the property values of the extracted fields that are mapped
+// Fields that don't fit their property's type are skipped with a warning
+func platformPropertyValues(info *platforms.Info, properties []platformProperty) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, property := range properties {
+		value, ok := info.Fields[property.Field]
+		if !ok {
+			continue
+		}
+		propertyValue, err := notion.PropertyValue(property.Type, value)
+		if err != nil {
+			fmt.Printf("  ⚠️  Skipping %s → %s: %v\n", property.Field, property.Property, err)
+			continue
+		}
+		values[property.Property] = propertyValue
+	}
+	return values
+}
@@ -0,0 +1,746 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/embeds"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/httpfetch"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/images"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/notion"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/overwrite"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/placeholder"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/platforms"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/ratelimit"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scrapecache"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraper"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/scraperules"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/snapshot"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/tables/bookmarks"
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/tables/tags"
+)
+
+// Processor hydrates a single bookmark: scrape, update properties, page content, cover and icon
+type Processor struct {
+	cfg               *Config
+	notionClient      *notion.Client
+	bookmarkService   *bookmarks.Service
+	scraperClient     scraper.Scraper
+	imageUploader     *notion.ImageUploader // nil when image upload is disabled
+	imageSelector     *images.Selector      // nil when image selection is disabled
+	faviconFinder     *images.FaviconFinder // nil when favicon discovery is disabled
+	snapshotFetcher   *httpfetch.Fetcher    // nil when snapshots are disabled
+	domainLimiter     *ratelimit.DomainLimiter
+	scrapeCache       *scrapecache.Cache   // nil when the scrape cache is disabled
+	refreshCache      bool                 // ignore cached scrapes but still store fresh ones (--no-cache)
+	degraded          bool                 // the built-in scraper stands in for the service; its results aren't cached
+	platformExtractor *platforms.Extractor // nil when no platform properties are mapped
+	platformProps     []platformProperty
+	scrapeRules       *scraperules.Rules // nil when no scrape rules are configured
+	tagService        *tags.Service
+	ruleTagIDs        map[string]string // rule tag name -> tag page ID
+}
+
+// NewProcessor creates a new bookmark processor
+// fetch holds the URL policy and transport of the image probes, favicon lookups and
+// snapshot downloads made from scraped URLs; its timeout and size limit are ignored
+func NewProcessor(cfg *Config, notionClient *notion.Client, bookmarkService *bookmarks.Service, scraperClient scraper.Scraper, imageUploader *notion.ImageUploader, fetch httpfetch.Options) *Processor {
+	p := &Processor{
+		cfg:             cfg,
+		notionClient:    notionClient,
+		bookmarkService: bookmarkService,
+		scraperClient:   scraperClient,
+		imageUploader:   imageUploader,
+		domainLimiter:   ratelimit.NewDomainLimiter(cfg.ScrapeDomainConcurrency, cfg.ScrapeDomainDelay),
+		tagService:      tags.NewService(notionClient),
+		ruleTagIDs:      make(map[string]string),
+	}
+
+	fetch.Timeout = cfg.ImageProbeTimeout
+	fetch.MaxBytes = cfg.ImageDownloadMaxBytes
+
+	if cfg.ImageSelectionEnabled {
+		// Probes only read image headers; oversized images are rejected up front like downloads
+		p.imageSelector = images.NewSelector(httpfetch.NewWithOptions(fetch), cfg.ImageMinWidth, cfg.ImageMinHeight, cfg.ImageMaxCandidates)
+	}
+
+	if cfg.FaviconDiscoveryEnabled {
+		p.faviconFinder = images.NewFaviconFinder(httpfetch.NewWithOptions(fetch))
+	}
+
+	if cfg.SnapshotEnabled {
+		// The snapshot image is embedded base64-encoded, which grows it by a third
+		snapshotFetch := fetch
+		snapshotFetch.MaxBytes = int64(cfg.SnapshotMaxBytes) * 3 / 4
+		p.snapshotFetcher = httpfetch.NewWithOptions(snapshotFetch)
+	}
+
+	return p
+}
+
+// SetScrapeCache makes the processor reuse scrape results stored on disk
+// With refresh set, cached results are ignored but fresh ones are still stored
+func (p *Processor) SetScrapeCache(cache *scrapecache.Cache, refresh bool) {
+	p.scrapeCache = cache
+	p.refreshCache = refresh
+}
+
+// SetDegraded marks the scraper as a stand-in for the unavailable scraper service
+// Its results are not cached, so the service's own results replace them once it is back
+func (p *Processor) SetDegraded() {
+	p.degraded = true
+}
+
+// SetPlatformProperties makes the processor write platform metadata to the mapped properties
+func (p *Processor) SetPlatformProperties(extractor *platforms.Extractor, properties []platformProperty) {
+	p.platformExtractor = extractor
+	p.platformProps = properties
+}
+
+// SetScrapeRules makes the processor skip or tag the bookmarks matching a scrape rule
+// The scraper applies the rest of the rule (rewrite, timeout, headers) itself
+func (p *Processor) SetScrapeRules(rules *scraperules.Rules) {
+	p.scrapeRules = rules
+}
+
+// Process scrapes and updates a single bookmark
+// Returns an error if the bookmark could not be processed
+func (p *Processor) Process(ctx context.Context, bookmark *bookmarks.Bookmark) error {
+	cfg := p.cfg
+
+	// Scrape rules may skip the bookmark or tag it before it is scraped
+	if rule := p.scrapeRules.Match(bookmark.URL); rule != nil {
+		fmt.Printf("📏 Scrape rule: %s\n", rule.Name)
+		if rule.Tag != "" {
+			p.addRuleTag(ctx, bookmark, rule.Tag)
+		}
+		if rule.Skip {
+			return p.skip(ctx, bookmark, rule)
+		}
+		if target := rule.RewriteURL(bookmark.URL); target != bookmark.URL {
+			fmt.Printf("↪️ Scraping %s instead\n", target)
+		}
+	}
+
+	// Scrape the bookmark
+	result, err := p.scrape(ctx, bookmark.URL)
+	if err != nil {
+		// On error: Set error field and mark as not processed
+		errorMsg := fmt.Sprintf("Failed to scrape URL: %v", err)
+		fmt.Printf("✗ %s\n", errorMsg)
+		fmt.Println()
+		fmt.Println("Updating bookmark with error...")
+
+		// Tags added by a scrape rule are kept on failed bookmarks too
+		_, updateErr := p.bookmarkService.SetError(ctx, bookmark.ID, errorMsg, bookmark.TagIDs...)
+		if updateErr != nil {
+			log.Printf("Failed to update bookmark with error: %v", updateErr)
+			return updateErr
+		}
+
+		fmt.Println("✓ Bookmark marked with error")
+		return err
+	}
+
+	// Print full raw JSON response (only if DEBUG is enabled)
+	if cfg.Debug {
+		fmt.Println("=== SCRAPED CONTENT (Full JSON) ===")
+		// Pretty print the raw JSON
+		var prettyJSON bytes.Buffer
+		if err := json.Indent(&prettyJSON, []byte(result.RawJSON), "", "  "); err == nil {
+			fmt.Println(prettyJSON.String())
+		} else {
+			// Fallback to raw JSON if indentation fails
+			fmt.Println(result.RawJSON)
+		}
+		fmt.Println("=== END OF SCRAPED CONTENT ===")
+		fmt.Println()
+	}
+
+	// Use the parsed content
+	content := result.Content
+
+	// Update bookmark with scraped metadata
+	fmt.Println("Updating bookmark with scraped metadata...")
+	updated := false
+
+	// Update the mapped properties (title, author, summary, date published) as their overwrite policies allow
+	updated = applyFieldMappings(bookmark, content, cfg.FieldMappings, cfg.Overwrite) || updated
+
+	// Pick the best image from the scraped content (try multiple sources)
+	// Skipped when neither the image property nor the cover may be written
+	var imageURL string
+	if cfg.Overwrite.Image.Allows(bookmark.ImageURL != "") || cfg.Overwrite.Cover.Allows(bookmark.Cover != "") {
+		imageURL = p.selectImage(ctx, bookmark.URL, result)
+	}
+
+	// Upload cover and favicon in the background while the page is updated
+	uploadCtx, cancelUploads := context.WithCancel(ctx)
+	cover := p.startCoverUpload(uploadCtx, bookmark, content, imageURL)
+	icon := p.startIconUpload(uploadCtx, bookmark, content)
+	defer func() {
+		// Uploads that never got attached (e.g. the bookmark update failed) must not be cached
+		cancelUploads()
+		cover.wait()
+		icon.wait()
+		if p.imageUploader != nil {
+			p.imageUploader.Discard(cover.fileUploadID, icon.fileUploadID)
+		}
+	}()
+
+	if !cfg.FallbackToExternalURL {
+		// A failed upload clears the image property, so the property update waits for the cover
+		cover.wait()
+		imageURL = cover.imageURL
+	}
+
+	// Update ImageURL property as its overwrite policy allows
+	updated = applyField(cfg.Overwrite.Image, &bookmark.ImageURL, imageURL, "image property") || updated
+
+	// Set date processed and full JSON
+	bookmark.DateProcessed = time.Now()
+
+	// Mark as processed and clear error
+	bookmark.Processed = true
+	bookmark.Error = ""
+
+	// Update the bookmark in Notion
+	_, err = p.bookmarkService.Update(ctx, bookmark.ID, bookmark)
+	if err != nil {
+		log.Printf("Failed to update bookmark: %v", err)
+		return err
+	}
+
+	if updated {
+		fmt.Println("✓ Bookmark metadata updated")
+	} else {
+		fmt.Println("  (No metadata property updates needed)")
+	}
+
+	if p.platformExtractor != nil {
+		p.applyPlatformProperties(ctx, bookmark, content)
+	}
+
+	// Update page content with full JSON as code block (erase all existing content)
+	// Platform links (videos, tweets, gists, ...) get a rich embed at the top
+	var blocks []notion.Block
+	if cfg.RichEmbedsEnabled {
+		if embed, ok := embeds.Detect(bookmark.URL, content.Metadata); ok {
+			fmt.Printf("  🔗 Detected %s link, adding %s block\n", embed.Platform, embed.Kind)
+			blocks = append(blocks, embed.Block())
+		}
+	}
+	blocks = append(blocks, notion.NewCode("json", notion.SplitText(formatPageJSON(result.RawJSON))...))
+
+	fmt.Println("  📝 Updating page content with full JSON...")
+	err = p.notionClient.ReplacePageContent(ctx, bookmark.ID, blocks...)
+	if err != nil {
+		fmt.Printf(" ⚠️  Failed to update page content: %v\n", err)
+		// Don't fail the whole process, just log the warning
+	} else {
+		fmt.Printf(" ✅ Page content updated\n")
+	}
+
+	// Attach an offline HTML snapshot (after the content update, which erases all blocks)
+	if cfg.SnapshotEnabled {
+		p.attachSnapshot(ctx, bookmark, content, imageURL)
+	}
+
+	cover.wait()
+	icon.wait()
+
+	// Set page cover if we have a FileUpload ID (always update cover)
+	var attachedCoverID, attachedIconID string
+	if cover.fileUploadID != "" {
+		fmt.Printf("  🖼️  Setting page cover...")
+		attachedCoverID, err = p.attachImage(ctx, bookmark.ID, cover.fileUploadID, p.notionClient.SetPageCover, cover.reupload)
+		if err != nil {
+			fmt.Printf(" ⚠️  Failed to set cover: %v\n", err)
+		} else {
+			fmt.Printf(" ✅ Cover set\n")
+		}
+	}
+
+	// Set page icon if we have a FileUpload ID
+	if icon.fileUploadID != "" {
+		fmt.Printf("  🖼️  Setting page icon...")
+		attachedIconID, err = p.attachImage(ctx, bookmark.ID, icon.fileUploadID, p.notionClient.SetPageIcon, icon.reupload)
+		if err != nil {
+			fmt.Printf(" ⚠️  Failed to set icon: %v\n", err)
+		} else {
+			fmt.Printf(" ✅ Icon set\n")
+		}
+	}
+
+	// Mirror the uploads into a Files & media property, which table views can show and filter
+	if cfg.MediaProperty != "" {
+		p.setMediaProperty(ctx, bookmark.ID, attachedCoverID, attachedIconID)
+	}
+
+	fmt.Println("✓ Bookmark marked as processed")
+	return nil
+}
+
+// applyField writes a scraped value into a bookmark field as its overwrite policy allows
+// Returns true when the field changed
+func applyField(policy overwrite.Policy, field *string, value, label string) bool {
+	if value == "" || value == *field || !policy.Allows(*field != "") {
+		return false
+	}
+	*field = value
+	fmt.Printf("  ✓ Set %s: %s\n", label, value)
+	return true
+}
+
+// backgroundUpload is a cover or icon upload running concurrently with the page updates
+type backgroundUpload struct {
+	done chan struct{}
+	// output collects progress lines so concurrent uploads don't interleave; printed by wait
+	output bytes.Buffer
+
+	fileUploadID string
+	// imageURL is the cover image property value after the upload ("" when the image was dropped)
+	imageURL string
+	// reupload uploads the same image again when a cached upload is refused
+	reupload func() (string, error)
+}
+
+// startUpload runs an upload in the background
+func startUpload(run func(u *backgroundUpload)) *backgroundUpload {
+	u := &backgroundUpload{done: make(chan struct{})}
+	go func() {
+		defer close(u.done)
+		run(u)
+	}()
+	return u
+}
+
+// wait blocks until the upload finished and prints its progress output once
+func (u *backgroundUpload) wait() {
+	<-u.done
+	if u.output.Len() > 0 {
+		fmt.Print(u.output.String())
+		u.output.Reset()
+	}
+}
+
+// startCoverUpload uploads the selected image, or a placeholder when there is none, as the page cover
+func (p *Processor) startCoverUpload(ctx context.Context, bookmark *bookmarks.Bookmark, content *scraper.ScrapedContent, imageURL string) *backgroundUpload {
+	cfg := p.cfg
+	// Read before the upload starts, since the property is updated while it runs
+	hasImage := imageURL != "" || bookmark.ImageURL != ""
+
+	return startUpload(func(u *backgroundUpload) {
+		u.imageURL = imageURL
+		if !cfg.UploadImagesToNotion || p.imageUploader == nil {
+			return
+		}
+		if !cfg.Overwrite.Cover.Allows(bookmark.Cover != "") {
+			if imageURL != "" || cfg.PlaceholderCoversEnabled {
+				fmt.Fprintf(&u.output, "  ⏭️  Keeping existing cover (overwrite policy: %s)\n", cfg.Overwrite.Cover)
+			}
+			return
+		}
+
+		u.reupload = func() (string, error) {
+			return p.imageUploader.UploadImageFromPage(ctx, imageURL, bookmark.URL)
+		}
+		if imageURL != "" {
+			fmt.Fprintf(&u.output, "  📤 Uploading image to Notion...")
+
+			fileUploadID, err := u.reupload()
+			if err != nil {
+				if cfg.FallbackToExternalURL {
+					fmt.Fprintf(&u.output, " ⚠️  Upload failed (%v), using external URL\n", err)
+					// Keep imageURL as-is for database property
+				} else {
+					fmt.Fprintf(&u.output, " ❌ Upload failed: %v\n", err)
+					u.imageURL = "" // Don't set any image
+				}
+			} else {
+				p.printUploaded(&u.output, fileUploadID)
+				u.fileUploadID = fileUploadID
+			}
+		}
+
+		// Generate a placeholder cover only when the bookmark has no image at all;
+		// a failed upload leaves the cover unset rather than replacing the real image
+		if !hasImage && cfg.PlaceholderCoversEnabled {
+			title := bookmark.Title
+			if title == "" && content.Metadata != nil {
+				title = content.Metadata.Title
+			}
+			u.reupload = func() (string, error) {
+				return p.uploadPlaceholder(ctx, title, bookmark.URL)
+			}
+
+			fmt.Fprintf(&u.output, "  🎨 Uploading placeholder cover...")
+			fileUploadID, err := u.reupload()
+			if err != nil {
+				fmt.Fprintf(&u.output, " ❌ Upload failed: %v\n", err)
+			} else {
+				fmt.Fprintf(&u.output, " ✅ Uploaded (ID: %s)\n", fileUploadID)
+				u.fileUploadID = fileUploadID
+			}
+		}
+	})
+}
+
+// startIconUpload uploads the scraped logo, or a favicon discovered on the page, as the page icon
+func (p *Processor) startIconUpload(ctx context.Context, bookmark *bookmarks.Bookmark, content *scraper.ScrapedContent) *backgroundUpload {
+	cfg := p.cfg
+
+	return startUpload(func(u *backgroundUpload) {
+		if !cfg.UploadImagesToNotion || p.imageUploader == nil {
+			return
+		}
+		if !cfg.Overwrite.Icon.Allows(bookmark.Icon != "") {
+			fmt.Fprintf(&u.output, "  ⏭️  Keeping existing icon (overwrite policy: %s)\n", cfg.Overwrite.Icon)
+			return
+		}
+
+		// Extract favicon URL from scraped content
+		var faviconURL string
+		if content.Metadata != nil && content.Metadata.Logo != nil && *content.Metadata.Logo != "" {
+			faviconURL = *content.Metadata.Logo
+		} else if p.faviconFinder != nil {
+			faviconURL = p.discoverFavicon(ctx, &u.output, bookmark.URL)
+		}
+		if faviconURL == "" {
+			return
+		}
+
+		u.reupload = func() (string, error) {
+			return p.imageUploader.UploadIconFromPage(ctx, faviconURL, bookmark.URL)
+		}
+
+		fmt.Fprintf(&u.output, "  📤 Uploading favicon to Notion...")
+		fileUploadID, err := u.reupload()
+		if err != nil {
+			fmt.Fprintf(&u.output, " ❌ Upload failed: %v\n", err)
+		} else {
+			p.printUploaded(&u.output, fileUploadID)
+			u.fileUploadID = fileUploadID
+		}
+	})
+}
+
+// setMediaProperty writes the attached cover (and favicon, if configured) into the media property
+func (p *Processor) setMediaProperty(ctx context.Context, pageID, coverID, iconID string) {
+	var files []notion.NamedFile
+	if coverID != "" {
+		files = append(files, notion.NamedFile{Name: "cover", FileSource: notion.FileUploadSource(coverID)})
+	}
+	if iconID != "" && p.cfg.MediaIncludeFavicon {
+		files = append(files, notion.NamedFile{Name: "favicon", FileSource: notion.FileUploadSource(iconID)})
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	fmt.Printf("  🗂️  Setting %s property...", p.cfg.MediaProperty)
+	if err := p.notionClient.SetFilesProperty(ctx, pageID, p.cfg.MediaProperty, files...); err != nil {
+		fmt.Printf(" ⚠️  Failed to set media: %v\n", err)
+		return
+	}
+	fmt.Printf(" ✅ %d file(s) set\n", len(files))
+}
+
+// printUploaded completes the "Uploading ..." progress line for an upload
+func (p *Processor) printUploaded(w io.Writer, fileUploadID string) {
+	if p.imageUploader.Reused(fileUploadID) {
+		fmt.Fprintf(w, " ♻️  Reused cached upload (ID: %s)\n", fileUploadID)
+	} else {
+		fmt.Fprintf(w, " ✅ Uploaded (ID: %s)\n", fileUploadID)
+	}
+}
+
+// attachImage sets an uploaded cover or icon on a page and records it in the upload cache
+// A cached upload Notion refuses is forgotten and the image is uploaded again once
+// Returns the file upload ID that ended up attached
+func (p *Processor) attachImage(ctx context.Context, pageID, fileUploadID string, set func(ctx context.Context, pageID, fileUploadID string) error, reupload func() (string, error)) (string, error) {
+	err := set(ctx, pageID, fileUploadID)
+	if err != nil && p.imageUploader.Reused(fileUploadID) {
+		// Copy the image from the page it was first attached to, then fall back to uploading it again
+		copiedID, copyErr := p.imageUploader.CopyFromSourcePage(ctx, fileUploadID)
+		if forgetErr := p.imageUploader.Invalidate(fileUploadID); forgetErr != nil {
+			log.Printf("Failed to update upload cache: %v", forgetErr)
+		}
+		if copyErr == nil {
+			fileUploadID = copiedID
+			if err = set(ctx, pageID, fileUploadID); err != nil {
+				p.imageUploader.Discard(fileUploadID)
+			}
+		}
+		if err != nil {
+			if fileUploadID, err = reupload(); err != nil {
+				return "", err
+			}
+			err = set(ctx, pageID, fileUploadID)
+		}
+	}
+	if err != nil {
+		p.imageUploader.Discard(fileUploadID)
+		return "", err
+	}
+
+	if err := p.imageUploader.MarkAttached(fileUploadID, pageID); err != nil {
+		log.Printf("Failed to update upload cache: %v", err)
+	}
+	return fileUploadID, nil
+}
+
+// uploadPlaceholder renders a cover with the title, domain and a per-domain color and uploads it
+func (p *Processor) uploadPlaceholder(ctx context.Context, title, pageURL string) (string, error) {
+	cover, err := placeholder.Render(title, pageURL)
+	if err != nil {
+		return "", err
+	}
+	return p.imageUploader.UploadImageData(ctx, cover.Filename, placeholder.ContentType, cover.Data)
+}
+
+// discoverFavicon looks for an icon declared by the page itself (link tags, web manifest, /favicon.ico)
+func (p *Processor) discoverFavicon(ctx context.Context, w io.Writer, pageURL string) string {
+	fmt.Fprintf(w, "  🔍 No logo from scraper, looking for a favicon...")
+
+	icon, err := p.faviconFinder.Find(ctx, pageURL)
+	if err != nil {
+		fmt.Fprintf(w, " ⚠️  %v\n", err)
+		return ""
+	}
+
+	if icon.ContentType == "image/svg+xml" {
+		fmt.Fprintf(w, " ✓ Found %s (SVG): %s\n", icon.Source, icon.URL)
+	} else {
+		fmt.Fprintf(w, " ✓ Found %s %dx%d: %s\n", icon.Source, icon.Width, icon.Height, icon.URL)
+	}
+	return icon.URL
+}
+
+// selectImage returns the cover image URL for a bookmark
+// With selection enabled every candidate is probed and tiny, broken or non-image URLs are rejected;
+// otherwise the first of content.Image → metadata.Image is used unchecked
+func (p *Processor) selectImage(ctx context.Context, pageURL string, result *scraper.ScrapeResult) string {
+	content := result.Content
+
+	if p.imageSelector == nil {
+		// Priority order: content.Image → metadata.Image
+		if content.Image != nil && *content.Image != "" {
+			return *content.Image
+		} else if content.Metadata != nil && content.Metadata.Image != nil && *content.Metadata.Image != "" {
+			return *content.Metadata.Image
+		}
+		return ""
+	}
+
+	candidates := images.CollectCandidates(pageURL, content, result.RawJSON)
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	fmt.Printf("  🔍 Checking %d image candidate(s)...\n", len(candidates))
+	best, probes := p.imageSelector.Select(ctx, candidates, pageURL)
+	for _, probe := range probes {
+		if probe.Err != nil {
+			fmt.Printf("    ✗ %s (%s): %v\n", probe.URL, probe.Source, probe.Err)
+		}
+	}
+
+	if best == nil {
+		fmt.Println("    No usable image found")
+		return ""
+	}
+
+	fmt.Printf("    ✓ Selected %s %dx%d: %s\n", best.Source, best.Width, best.Height, best.URL)
+	return best.URL
+}
+
+// attachSnapshot uploads an HTML snapshot of the scraped content and attaches it to the bookmark page
+// Failures are logged as warnings and never fail the bookmark
+func (p *Processor) attachSnapshot(ctx context.Context, bookmark *bookmarks.Bookmark, content *scraper.ScrapedContent, imageURL string) {
+	fmt.Printf("  📸 Uploading HTML snapshot...")
+
+	var image *snapshot.Image
+	if imageURL != "" {
+		var err error
+		if image, err = p.fetchSnapshotImage(ctx, imageURL, bookmark.URL); err != nil {
+			fmt.Printf(" ⚠️  Leaving out the image (%v)...", err)
+		}
+	}
+
+	snap, err := snapshot.Build(bookmark.URL, content, image, p.cfg.SnapshotMaxBytes)
+	if err != nil {
+		fmt.Printf(" ⚠️  Failed to build snapshot: %v\n", err)
+		return
+	}
+
+	upload, err := p.notionClient.UploadFile(ctx, snap.Filename, snapshot.ContentType, snap.HTML)
+	if err != nil {
+		fmt.Printf(" ⚠️  Failed to upload snapshot: %v\n", err)
+		return
+	}
+
+	source := notion.FileUploadSource(upload.ID)
+	if p.cfg.SnapshotProperty != "" {
+		err = p.notionClient.SetFilesProperty(ctx, bookmark.ID, p.cfg.SnapshotProperty,
+			notion.NamedFile{Name: snap.Filename, FileSource: source})
+	} else {
+		err = p.notionClient.AppendBlocks(ctx, bookmark.ID, notion.NewFile(source, snap.Filename))
+	}
+	if err != nil {
+		fmt.Printf(" ⚠️  Failed to attach snapshot: %v\n", err)
+		return
+	}
+
+	switch {
+	case snap.ImageDropped:
+		fmt.Printf(" ✅ Snapshot attached (%d bytes, image left out to fit)\n", len(snap.HTML))
+	case snap.Truncated:
+		fmt.Printf(" ✅ Snapshot attached (%d bytes, truncated)\n", len(snap.HTML))
+	default:
+		fmt.Printf(" ✅ Snapshot attached (%d bytes)\n", len(snap.HTML))
+	}
+}
+
+// fetchSnapshotImage downloads the selected image for embedding in the snapshot
+func (p *Processor) fetchSnapshotImage(ctx context.Context, imageURL, pageURL string) (*snapshot.Image, error) {
+	resp, err := p.snapshotFetcher.Get(ctx, imageURL, pageURL, httpfetch.AcceptImage)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := p.snapshotFetcher.ReadBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	contentType := httpfetch.ImageContentType(resp.Header.Get("Content-Type"), data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("not an image (%s)", contentType)
+	}
+	return &snapshot.Image{ContentType: contentType, Data: data}, nil
+}
+
+// applyPlatformProperties extracts platform metadata and writes it to the mapped properties
+// Mapped properties are always overwritten; failures are logged as warnings and never fail the bookmark
+func (p *Processor) applyPlatformProperties(ctx context.Context, bookmark *bookmarks.Bookmark, content *scraper.ScrapedContent) {
+	info, err := p.platformExtractor.Extract(ctx, bookmark.URL, content)
+	if err != nil {
+		fmt.Printf("  ⚠️  Failed to fetch platform metadata: %v\n", err)
+	}
+	if info.Platform != "" {
+		fmt.Printf("  🧩 %s: %s\n", info.Platform, info.Summary())
+	}
+
+	values := platformPropertyValues(info, p.platformProps)
+	if len(values) == 0 {
+		return
+	}
+	if err := p.notionClient.SetProperties(ctx, bookmark.ID, values); err != nil {
+		fmt.Printf("  ⚠️  Failed to set platform properties: %v\n", err)
+		return
+	}
+	fmt.Printf("  ✅ Platform properties updated (%d)\n", len(values))
+}
+
+// addRuleTag adds a scrape rule's tag to the bookmark, creating the tag on first use
+// Failures are logged as warnings and never fail the bookmark
+func (p *Processor) addRuleTag(ctx context.Context, bookmark *bookmarks.Bookmark, name string) {
+	tagID, ok := p.ruleTagIDs[name]
+	if !ok {
+		tag, err := p.tagService.FindOrCreate(ctx, name)
+		if err != nil {
+			fmt.Printf("  ⚠️  Failed to find or create tag %q: %v\n", name, err)
+			return
+		}
+		tagID = tag.ID
+		p.ruleTagIDs[name] = tagID
+	}
+
+	if slices.Contains(bookmark.TagIDs, tagID) {
+		return
+	}
+	bookmark.TagIDs = append(bookmark.TagIDs, tagID)
+	fmt.Printf("  🏷️  Tagged %q\n", name)
+}
+
+// skip marks a bookmark processed without scraping it, as a scrape rule asks
+func (p *Processor) skip(ctx context.Context, bookmark *bookmarks.Bookmark, rule *scraperules.Rule) error {
+	fmt.Printf("⏭️ Skipped by scrape rule %s\n", rule.Name)
+
+	bookmark.DateProcessed = time.Now()
+	bookmark.Processed = true
+	bookmark.Error = ""
+	if _, err := p.bookmarkService.Update(ctx, bookmark.ID, bookmark); err != nil {
+		log.Printf("Failed to update bookmark: %v", err)
+		return err
+	}
+
+	fmt.Println("✓ Bookmark marked as processed")
+	return nil
+}
+
+// scrape returns the scraped content of a URL, from the scrape cache when it holds a fresh entry
+func (p *Processor) scrape(ctx context.Context, url string) (*scraper.ScrapeResult, error) {
+	// The scraper fetches the rewritten URL, so it keys the cache and the site limits
+	target := p.scrapeRules.Match(url).RewriteURL(url)
+
+	if p.scrapeCache != nil && !p.refreshCache {
+		if entry, ok := p.scrapeCache.Get(target); ok {
+			if result, err := scraper.DecodeResult(entry.RawJSON); err == nil {
+				fmt.Printf("♻️ Using cached scrape from %s ago\n", entry.Age().Round(time.Second))
+				return result, nil
+			}
+		}
+	}
+
+	// Cached results never reach the site, so only live scrapes wait for their turn
+	release, err := p.domainLimiter.Acquire(ctx, target)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	fmt.Println("Scraping content...")
+	result, err := p.scraperClient.Scrape(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.scrapeCache != nil && !p.degraded {
+		if err := p.scrapeCache.Put(target, result.RawJSON); err != nil {
+			fmt.Printf("⚠️ Failed to cache scrape result: %v\n", err)
+		}
+	}
+	return result, nil
+}
+
+// formatPageJSON prepares the raw scraper JSON for the page code block:
+// the "content" field is truncated and the result is pretty-printed
+func formatPageJSON(rawJSON string) string {
+	jsonContent := rawJSON
+	var jsonData map[string]interface{}
+	if err := json.Unmarshal([]byte(rawJSON), &jsonData); err == nil {
+		if content, ok := jsonData["content"].(string); ok && len(content) > 250 {
+			jsonData["content"] = content[:250] + " [truncated]"
+			if modifiedJSON, err := json.Marshal(jsonData); err == nil {
+				jsonContent = string(modifiedJSON)
+			}
+		}
+	}
+
+	// Pretty-print the JSON for better readability in the code block
+	var prettyJSON bytes.Buffer
+	if err := json.Indent(&prettyJSON, []byte(jsonContent), "", "  "); err == nil {
+		jsonContent = prettyJSON.String()
+	}
+
+	return jsonContent
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pgodinho/hidrate-notion-bookmarks/pkg/tables/bookmarks"
+)
+
+// TestProcessReplaysCassette hydrates a bookmark from a synthetic cassette: its responses were
+// written by hand and the requests captured by recording Process against them. The scrape,
+// the direct cover download and every Notion call are answered by the cassette, so the test
+// fails if Process makes a request the cassette doesn't hold
+func TestProcessReplaysCassette(t *testing.T) {
+	for key, value := range map[string]string{
+		"NOTION_API_KEY":             "secret_test",
+		"NOTION_BOOKMARKS_DB_ID":     "bookmarks-db",
+		"NOTION_TAGS_DB_ID":          "tags-db",
+		"NOTION_SMARTLIST_DB_ID":     "smartlist-db",
+		"NOTION_MANUALLIST_DB_ID":    "manuallist-db",
+		"WEBMEATSCRAPER_URL":         "http://scraper.test",
+		"SCRAPER_RETRIES":            "0",
+		"IMAGE_UPLOAD_MODE":          "direct",
+		"IMAGE_UPLOAD_POLL_INTERVAL": "10ms",
+		"IMAGE_TRANSCODE_ENABLED":    "false",
+		"IMAGE_SELECTION_ENABLED":    "false",
+		"FAVICON_DISCOVERY_ENABLED":  "false",
+		"SCRAPE_CACHE_ENABLED":       "false",
+		"UPLOAD_CACHE_ENABLED":       "false",
+		"HTTP_CASSETTE_MODE":         "replay",
+		"HTTP_CASSETTE_PATH":         "testdata/cassettes/process_synthetic.jsonl",
+	} {
+		t.Setenv(key, value)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// The same wiring as a replayed run of the command
+	c, err := newClients(cfg)
+	if err != nil {
+		t.Fatalf("newClients: %v", err)
+	}
+	defer c.Close()
+	processor := c.newProcessor(cfg)
+
+	bookmark := &bookmarks.Bookmark{
+		ID:    "1a2b3c4d-0000-4000-8000-000000000001",
+		Title: "Hydration",
+		URL:   "https://example.com/articles/hydration",
+	}
+	if err := processor.Process(context.Background(), bookmark); err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	if !bookmark.Processed || bookmark.Error != "" {
+		t.Errorf("bookmark not marked processed: processed=%v error=%q", bookmark.Processed, bookmark.Error)
+	}
+	if bookmark.Author != "Ada Lovelace" {
+		t.Errorf("Author = %q, want the scraped author", bookmark.Author)
+	}
+	if bookmark.ImageURL != "https://example.com/images/cover.png" {
+		t.Errorf("ImageURL = %q, want the scraped image", bookmark.ImageURL)
+	}
+	if remaining := c.replayer.Remaining(); remaining != 0 {
+		t.Errorf("%d recorded interaction(s) were not replayed", remaining)
+	}
+}